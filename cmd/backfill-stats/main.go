@@ -0,0 +1,59 @@
+// Command backfill-stats rebuilds the daily stats rollups from the settled rounds and results.
+//
+// Usage:
+//
+//	go run ./cmd/backfill-stats [-from 2024-01-01] [-to 2024-01-31]
+//
+// Both dates are UTC days and inclusive. Without -from every day up to -to (yesterday by default)
+// is rebuilt. The current day cannot be rebuilt, since rounds settling during the rebuild would
+// be lost or counted twice.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/config"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/HSouheil/bucketball_backend/services"
+)
+
+func main() {
+	fromFlag := flag.String("from", "", "first day to rebuild (YYYY-MM-DD), defaults to the beginning of history")
+	toFlag := flag.String("to", "", "last day to rebuild (YYYY-MM-DD), defaults to yesterday")
+	flag.Parse()
+
+	from := time.Unix(0, 0).UTC()
+	if *fromFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *fromFlag)
+		if err != nil {
+			log.Fatalf("Invalid -from date: %v", err)
+		}
+		from = parsed
+	}
+
+	to := time.Now().UTC().AddDate(0, 0, -1)
+	if *toFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *toFlag)
+		if err != nil {
+			log.Fatalf("Invalid -to date: %v", err)
+		}
+		to = parsed
+	}
+
+	cfg := config.LoadConfig()
+	mongoClient := config.InitMongoDB(cfg)
+	defer config.CloseDatabases()
+	db := mongoClient.Database(cfg.MongoDB.Database)
+
+	statsService := services.NewStatsService(repositories.NewStatsRepository(db), repositories.NewGameRepository(db))
+
+	log.Printf("Rebuilding daily stats from %s to %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	started := time.Now()
+	if err := statsService.Backfill(context.Background(), from, to); err != nil {
+		log.Fatalf("Failed to rebuild daily stats: %v", err)
+	}
+	log.Printf("Daily stats rebuilt in %s", time.Since(started).Round(time.Millisecond))
+}
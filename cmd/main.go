@@ -10,6 +10,7 @@ import (
 	customMiddleware "github.com/HSouheil/bucketball_backend/middleware"
 	"github.com/HSouheil/bucketball_backend/repositories"
 	"github.com/HSouheil/bucketball_backend/routes"
+	"github.com/HSouheil/bucketball_backend/utils"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -22,6 +23,13 @@ func main() {
 	// Initialize Echo
 	e := echo.New()
 
+	// Resolve client IPs (c.RealIP) for sessions, audit logs and rate limits,
+	// trusting forwarding headers only from the configured proxies
+	e.IPExtractor = utils.NewIPExtractor(cfg.Server.TrustedProxies)
+	if len(cfg.Server.TrustedProxies) == 0 {
+		log.Println("No TRUSTED_PROXIES set, ignoring X-Forwarded-For and X-Real-IP")
+	}
+
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
@@ -1,26 +1,33 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Server  ServerConfig
-	MongoDB MongoDBConfig
-	Redis   RedisConfig
-	JWT     JWTConfig
-	App     AppConfig
-	Email   EmailConfig
+	Server    ServerConfig
+	MongoDB   MongoDBConfig
+	Redis     RedisConfig
+	JWT       JWTConfig
+	App       AppConfig
+	Email     EmailConfig
+	RateLimit RateLimitConfig
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Host string
+	Port           string
+	Host           string
+	TrustedProxies []*net.IPNet // proxies whose forwarding headers are believed
 }
 
 // MongoDBConfig holds MongoDB configuration
@@ -38,7 +45,9 @@ type RedisConfig struct {
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	Secret string
+	Secret          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
 }
 
 // AppConfig holds general app configuration
@@ -48,8 +57,17 @@ type AppConfig struct {
 	Version     string
 }
 
+// Mail transports
+const (
+	MailTransportSMTP   = "smtp"
+	MailTransportFile   = "file"   // append to a local mbox file
+	MailTransportMemory = "memory" // keep in memory for the dev mailbox endpoint
+)
+
 // EmailConfig holds email configuration
 type EmailConfig struct {
+	Transport    string
+	MboxPath     string
 	SMTPHost     string
 	SMTPPort     string
 	SMTPUsername string
@@ -58,6 +76,40 @@ type EmailConfig struct {
 	FromName     string
 }
 
+// Rate limit scopes
+const (
+	RateLimitByIP   = "ip"   // count requests per client IP
+	RateLimitByUser = "user" // count requests per authenticated user, per IP before login
+)
+
+// Rate limit policy names
+const (
+	RateLimitRegister       = "register"
+	RateLimitVerifyEmail    = "verify_email"
+	RateLimitResendOTP      = "resend_otp"
+	RateLimitLogin          = "login"
+	RateLimitTwoFactor      = "two_factor"
+	RateLimitTwoFactorSetup = "two_factor_setup"
+	RateLimitRefresh        = "refresh"
+	RateLimitForgotPassword = "forgot_password"
+	RateLimitResetPassword  = "reset_password"
+	RateLimitUsers          = "users"
+	RateLimitGames          = "games"
+	RateLimitAdmin          = "admin"
+)
+
+// RateLimitPolicy allows Requests per sliding Window for each client of a scope
+type RateLimitPolicy struct {
+	Requests int
+	Window   time.Duration
+	Scope    string
+}
+
+// RateLimitConfig holds the rate limit policies by name
+type RateLimitConfig struct {
+	Policies map[string]RateLimitPolicy
+}
+
 var cfg *Config
 
 // LoadConfig loads configuration from environment variables
@@ -73,8 +125,9 @@ func LoadConfig() *Config {
 
 	cfg = &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Host: getEnv("HOST", "0.0.0.0"),
+			Port:           getEnv("PORT", "8080"),
+			Host:           getEnv("HOST", "0.0.0.0"),
+			TrustedProxies: parseTrustedProxies(getEnv("TRUSTED_PROXIES", "")),
 		},
 		MongoDB: MongoDBConfig{
 			URI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
@@ -86,26 +139,129 @@ func LoadConfig() *Config {
 			DB:       0,
 		},
 		JWT: JWTConfig{
-			Secret: requiredEnv("JWT_SECRET"),
+			Secret:          requiredEnv("JWT_SECRET"),
+			AccessTokenTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+			RefreshTokenTTL: getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
 		},
 		App: AppConfig{
 			Environment: getEnv("ENV", "development"),
 			Name:        getEnv("APP_NAME", "BucketBall Backend"),
 			Version:     getEnv("APP_VERSION", "1.0.0"),
 		},
-		Email: EmailConfig{
-			SMTPHost:     requiredEnv("SMTP_HOST"),
-			SMTPPort:     getEnv("SMTP_PORT", "587"),
-			SMTPUsername: requiredEnv("SMTP_USERNAME"),
-			SMTPPassword: requiredEnv("SMTP_PASSWORD"),
-			FromEmail:    requiredEnv("FROM_EMAIL"),
-			FromName:     getEnv("FROM_NAME", "BucketBall"),
-		},
+		Email:     loadEmailConfig(),
+		RateLimit: loadRateLimitConfig(),
 	}
 
 	return cfg
 }
 
+// parseTrustedProxies parses a comma-separated list of proxy CIDRs or single IPs
+func parseTrustedProxies(value string) []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Fatalf("Invalid TRUSTED_PROXIES entry %q: %v", entry, err)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies
+}
+
+// loadEmailConfig loads the mail transport settings; SMTP credentials are only required for the SMTP transport
+func loadEmailConfig() EmailConfig {
+	email := EmailConfig{
+		Transport: getEnv("MAIL_TRANSPORT", MailTransportSMTP),
+		MboxPath:  getEnv("MAIL_MBOX_PATH", "mail/outbox.mbox"),
+		SMTPPort:  getEnv("SMTP_PORT", "587"),
+		FromEmail: getEnv("FROM_EMAIL", "noreply@bucketball.local"),
+		FromName:  getEnv("FROM_NAME", "BucketBall"),
+	}
+
+	switch email.Transport {
+	case MailTransportSMTP:
+		email.SMTPHost = requiredEnv("SMTP_HOST")
+		email.SMTPUsername = requiredEnv("SMTP_USERNAME")
+		email.SMTPPassword = requiredEnv("SMTP_PASSWORD")
+		email.FromEmail = requiredEnv("FROM_EMAIL")
+	case MailTransportMemory:
+		// Captured emails are never delivered, which would lock real users out
+		if getEnv("ENV", "development") == "production" {
+			log.Fatal("MAIL_TRANSPORT=memory is not allowed in production")
+		}
+	case MailTransportFile:
+	default:
+		log.Fatalf("Unknown MAIL_TRANSPORT %q (expected smtp, file or memory)", email.Transport)
+	}
+
+	return email
+}
+
+// loadRateLimitConfig loads the rate limit policies. Each one can be overridden with
+// RATE_LIMIT_<NAME>=<requests>/<window>, e.g. RATE_LIMIT_LOGIN=20/5m.
+func loadRateLimitConfig() RateLimitConfig {
+	policies := map[string]RateLimitPolicy{
+		RateLimitRegister:       {Requests: 5, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitVerifyEmail:    {Requests: 10, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitResendOTP:      {Requests: 5, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitLogin:          {Requests: 10, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitTwoFactor:      {Requests: 10, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitTwoFactorSetup: {Requests: 5, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitRefresh:        {Requests: 30, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitForgotPassword: {Requests: 5, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitResetPassword:  {Requests: 10, Window: time.Minute, Scope: RateLimitByIP},
+		RateLimitUsers:          {Requests: 100, Window: time.Hour, Scope: RateLimitByUser},
+		RateLimitGames:          {Requests: 50, Window: time.Hour, Scope: RateLimitByUser},
+		RateLimitAdmin:          {Requests: 200, Window: time.Hour, Scope: RateLimitByUser},
+	}
+
+	for name, policy := range policies {
+		key := "RATE_LIMIT_" + strings.ToUpper(name)
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		requests, window, err := parseRateLimit(value)
+		if err != nil {
+			log.Printf("Invalid %s (%v), using default %d/%v", key, err, policy.Requests, policy.Window)
+			continue
+		}
+		policy.Requests = requests
+		policy.Window = window
+		policies[name] = policy
+	}
+
+	return RateLimitConfig{Policies: policies}
+}
+
+// parseRateLimit parses a "<requests>/<window>" limit such as "10/1m"
+func parseRateLimit(value string) (int, time.Duration, error) {
+	count, period, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("expected <requests>/<window>")
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || requests <= 0 {
+		return 0, 0, fmt.Errorf("requests must be a positive integer")
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("window must be a positive duration")
+	}
+	return requests, window, nil
+}
+
 // GetConfig returns the current configuration
 func GetConfig() *Config {
 	if cfg == nil {
@@ -122,6 +278,21 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvDuration gets a duration environment variable (e.g. "15m") with a fallback value
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid duration for %s, using default %v", key, fallback)
+		return fallback
+	}
+	return duration
+}
+
 // requiredEnv gets a required environment variable or panics if not found
 func requiredEnv(key string) string {
 	value := os.Getenv(key)
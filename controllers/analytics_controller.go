@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type AnalyticsController struct {
+	analyticsService *services.AnalyticsService
+}
+
+// NewAnalyticsController creates a new analytics controller
+func NewAnalyticsController(analyticsService *services.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetOperatorAnalytics gets GGR, payouts, revenue and player metrics over time (admin only)
+func (ac *AnalyticsController) GetOperatorAnalytics(c echo.Context) error {
+	from, err := timeQueryParam(c, "from")
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	to, err := timeQueryParam(c, "to")
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	analytics, err := ac.analyticsService.GetOperatorAnalytics(ctx, from, to, c.QueryParam("granularity"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get analytics", err)
+	}
+
+	return utils.SuccessResponse(c, "Analytics retrieved successfully", analytics)
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditController struct {
+	auditService *services.AuditService
+}
+
+// NewAuditController creates a new audit controller
+func NewAuditController(auditService *services.AuditService) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+// SearchLogs searches the audit log by actor, action, target and time range
+func (ac *AuditController) SearchLogs(c echo.Context) error {
+	filter := &models.AuditLogFilter{
+		ActorID:    c.QueryParam("actor_id"),
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target_id"),
+	}
+
+	// Parse time range (RFC 3339)
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return utils.BadRequestResponse(c, "from must be an RFC 3339 timestamp")
+		}
+		filter.From = &t
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return utils.BadRequestResponse(c, "to must be an RFC 3339 timestamp")
+		}
+		filter.To = &t
+	}
+
+	// Parse pagination parameters
+	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+
+	ctx := c.Request().Context()
+	entries, total, err := ac.auditService.SearchLogs(ctx, filter, page, limit)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to search audit logs", err)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	response := map[string]interface{}{
+		"logs": entries,
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	}
+
+	return utils.SuccessResponse(c, "Audit logs retrieved successfully", response)
+}
@@ -44,6 +44,7 @@ func (ac *AuthController) Register(c echo.Context) error {
 		req.DOB = c.FormValue("dob")
 		req.PhoneNumber = c.FormValue("phone_number")
 		req.ReferralCode = c.FormValue("referral_code")
+		req.Language = c.FormValue("language")
 
 		// Handle profile picture upload
 		file, err := c.FormFile("profile_pic")
@@ -65,6 +66,11 @@ func (ac *AuthController) Register(c echo.Context) error {
 		return utils.ValidationErrorResponse(c, "Validation failed", err)
 	}
 
+	// Fall back to the browser's language for emails
+	if req.Language == "" {
+		req.Language = c.Request().Header.Get("Accept-Language")
+	}
+
 	ctx := c.Request().Context()
 	user, _, err := ac.authService.Register(ctx, &req)
 	if err != nil {
@@ -101,8 +107,8 @@ func (ac *AuthController) Login(c echo.Context) error {
 	}
 
 	ctx := c.Request().Context()
-	clientIP := c.RealIP()
-	user, token, err := ac.authService.Login(ctx, &req, clientIP)
+	client := utils.GetClientInfo(c)
+	user, tokens, challenge, err := ac.authService.Login(ctx, &req, client)
 	if err != nil {
 		// Check if it's a rate limit error
 		if strings.Contains(err.Error(), "too many login attempts") {
@@ -111,6 +117,11 @@ func (ac *AuthController) Login(c echo.Context) error {
 		return utils.UnauthorizedResponse(c, err.Error())
 	}
 
+	// Password accepted, second factor still required
+	if challenge != nil {
+		return utils.SuccessResponse(c, "Two-factor authentication required", challenge)
+	}
+
 	// Return response
 	userResponse := models.UserResponse{
 		ID:               user.ID,
@@ -130,13 +141,17 @@ func (ac *AuthController) Login(c echo.Context) error {
 		ReferralCode:     user.ReferralCode,
 		ReferredBy:       user.ReferredBy,
 		ReferralEarnings: user.ReferralEarnings,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		Language:         user.Language,
 		CreatedAt:        user.CreatedAt,
 		UpdatedAt:        user.UpdatedAt,
 	}
 
 	authResponse := models.AuthResponse{
-		Token: token,
-		User:  userResponse,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         userResponse,
 	}
 
 	return utils.SuccessResponse(c, "Login successful", authResponse)
@@ -187,6 +202,8 @@ func (ac *AuthController) GetProfile(c echo.Context) error {
 		ReferralCode:     user.ReferralCode,
 		ReferredBy:       user.ReferredBy,
 		ReferralEarnings: user.ReferralEarnings,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		Language:         user.Language,
 		CreatedAt:        user.CreatedAt,
 		UpdatedAt:        user.UpdatedAt,
 	}
@@ -198,7 +215,7 @@ func (ac *AuthController) GetProfile(c echo.Context) error {
 func (ac *AuthController) UpdateProfile(c echo.Context) error {
 	userID := c.Get("user_id").(string)
 
-	var req models.UpdateUserRequest
+	var req models.UpdateProfileRequest
 	if err := c.Bind(&req); err != nil {
 		return utils.ValidationErrorResponse(c, "Invalid request data", err)
 	}
@@ -233,7 +250,7 @@ func (ac *AuthController) VerifyEmail(c echo.Context) error {
 	}
 
 	ctx := c.Request().Context()
-	token, err := ac.authService.VerifyEmailAndGenerateToken(ctx, req.Email, req.OTP)
+	user, tokens, err := ac.authService.VerifyEmailAndGenerateToken(ctx, req.Email, req.OTP, utils.GetClientInfo(c))
 	if err != nil {
 		if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "expired") || strings.Contains(err.Error(), "used") {
 			return utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
@@ -241,45 +258,61 @@ func (ac *AuthController) VerifyEmail(c echo.Context) error {
 		return utils.InternalServerErrorResponse(c, "Failed to verify email", err)
 	}
 
-	// Get updated user
-	user, err := ac.authService.GetUserByID(ctx, "")
-	if err == nil {
-		userResponse := models.UserResponse{
-			ID:               user.ID,
-			Email:            user.Email,
-			Username:         user.Username,
-			FirstName:        user.FirstName,
-			LastName:         user.LastName,
-			ProfilePic:       user.ProfilePic,
-			DOB:              user.DOB,
-			PhoneNumber:      user.PhoneNumber,
-			Location:         user.Location,
-			Balance:          user.Balance,
-			Withdraw:         user.Withdraw,
-			Role:             user.Role,
-			IsActive:         user.IsActive,
-			IsEmailVerified:  user.IsEmailVerified,
-			ReferralCode:     user.ReferralCode,
-			ReferredBy:       user.ReferredBy,
-			ReferralEarnings: user.ReferralEarnings,
-			CreatedAt:        user.CreatedAt,
-			UpdatedAt:        user.UpdatedAt,
-		}
+	userResponse := models.UserResponse{
+		ID:               user.ID,
+		Email:            user.Email,
+		Username:         user.Username,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		ProfilePic:       user.ProfilePic,
+		DOB:              user.DOB,
+		PhoneNumber:      user.PhoneNumber,
+		Location:         user.Location,
+		Balance:          user.Balance,
+		Withdraw:         user.Withdraw,
+		Role:             user.Role,
+		IsActive:         user.IsActive,
+		IsEmailVerified:  user.IsEmailVerified,
+		ReferralCode:     user.ReferralCode,
+		ReferredBy:       user.ReferredBy,
+		ReferralEarnings: user.ReferralEarnings,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		Language:         user.Language,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+	}
 
-		authResponse := models.AuthResponse{
-			Token: token,
-			User:  userResponse,
-		}
+	authResponse := models.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         userResponse,
+	}
+
+	return utils.SuccessResponse(c, "Email verified successfully", authResponse)
+}
 
-		return utils.SuccessResponse(c, "Email verified successfully", authResponse)
+// RefreshToken exchanges a refresh token for a new token pair
+func (ac *AuthController) RefreshToken(c echo.Context) error {
+	var req models.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
 	}
 
-	// Fallback if user retrieval fails
-	response := map[string]interface{}{
-		"token": token,
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
 	}
 
-	return utils.SuccessResponse(c, "Email verified successfully", response)
+	ctx := c.Request().Context()
+	tokens, err := ac.authService.RefreshTokens(ctx, req.RefreshToken, utils.GetClientInfo(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "reuse") || strings.Contains(err.Error(), "deactivated") {
+			return utils.UnauthorizedResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to refresh token", err)
+	}
+
+	return utils.SuccessResponse(c, "Token refreshed successfully", tokens)
 }
 
 // ResendOTP handles resending OTP
@@ -310,6 +343,132 @@ func (ac *AuthController) ResendOTP(c echo.Context) error {
 	return utils.SuccessResponse(c, "OTP resent successfully", nil)
 }
 
+// ForgotPassword handles password reset requests
+func (ac *AuthController) ForgotPassword(c echo.Context) error {
+	var req models.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	if err := ac.authService.ForgotPassword(ctx, req.Email); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to process password reset request", err)
+	}
+
+	// Same response whether or not the account exists
+	return utils.SuccessResponse(c, "If an account exists for this email, a password reset code has been sent", nil)
+}
+
+// ResetPassword handles setting a new password with a reset OTP
+func (ac *AuthController) ResetPassword(c echo.Context) error {
+	var req models.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	if err := ac.authService.ResetPassword(ctx, req.Email, req.OTP, req.NewPassword); err != nil {
+		if strings.Contains(err.Error(), "invalid or expired") {
+			return utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to reset password", err)
+	}
+
+	return utils.SuccessResponse(c, "Password reset successfully. Please log in with your new password.", nil)
+}
+
+// ChangePassword handles changing the current user's password
+func (ac *AuthController) ChangePassword(c echo.Context) error {
+	var req models.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	userID := c.Get("user_id").(string)
+	sessionID, _ := c.Get("session_id").(string)
+
+	ctx := c.Request().Context()
+	if err := ac.authService.ChangePassword(ctx, userID, sessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		if strings.Contains(err.Error(), "incorrect") {
+			return utils.UnauthorizedResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "must be different") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to change password", err)
+	}
+
+	return utils.SuccessResponse(c, "Password changed successfully. Other devices have been signed out.", nil)
+}
+
+// RequestEmailChange handles starting an email change for the current user
+func (ac *AuthController) RequestEmailChange(c echo.Context) error {
+	var req models.ChangeEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	userID := c.Get("user_id").(string)
+	ctx := c.Request().Context()
+	if err := ac.authService.RequestEmailChange(ctx, userID, req.NewEmail, req.Password); err != nil {
+		if strings.Contains(err.Error(), "invalid password") {
+			return utils.UnauthorizedResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "already in use") {
+			return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		}
+		if strings.Contains(err.Error(), "must be different") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to start email change", err)
+	}
+
+	return utils.SuccessResponse(c, "A verification code has been sent to the new email address", nil)
+}
+
+// ConfirmEmailChange handles confirming the new email address with an OTP
+func (ac *AuthController) ConfirmEmailChange(c echo.Context) error {
+	var req models.ConfirmEmailChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	userID := c.Get("user_id").(string)
+	ctx := c.Request().Context()
+	email, err := ac.authService.ConfirmEmailChange(ctx, userID, req.OTP)
+	if err != nil {
+		if strings.Contains(err.Error(), "already in use") {
+			return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		}
+		if strings.Contains(err.Error(), "OTP") || strings.Contains(err.Error(), "no email change") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to change email", err)
+	}
+
+	return utils.SuccessResponse(c, "Email changed successfully", map[string]string{"email": email})
+}
+
 // GetReferralStats gets referral statistics for the current user
 func (ac *AuthController) GetReferralStats(c echo.Context) error {
 	userID := c.Get("user_id").(string)
@@ -364,3 +523,29 @@ func (ac *AuthController) ProcessPayment(c echo.Context) error {
 
 	return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment type", nil)
 }
+
+// GetTransactions gets a page of transactions for the current user
+func (ac *AuthController) GetTransactions(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	filter := &models.TransactionFilter{Type: c.QueryParam("type")}
+	if filter.From, filter.To, err = queryTimeRange(c); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	transactions, err := ac.paymentService.GetPaymentHistory(ctx, objectID, filter, c.QueryParam("cursor"), cursorLimit(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get transactions", err)
+	}
+
+	return utils.SuccessResponse(c, "Transactions retrieved successfully", transactions)
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type DevController struct {
+	mailbox *services.MemoryTransport
+}
+
+// NewDevController creates a new development tools controller
+func NewDevController(mailbox *services.MemoryTransport) *DevController {
+	return &DevController{
+		mailbox: mailbox,
+	}
+}
+
+// GetMailbox lists the emails captured by the in-memory mail transport, optionally filtered by recipient
+func (dc *DevController) GetMailbox(c echo.Context) error {
+	messages := dc.mailbox.Messages(c.QueryParam("to"))
+	return utils.SuccessResponse(c, "Mailbox retrieved successfully", map[string]interface{}{
+		"messages": messages,
+	})
+}
+
+// ClearMailbox removes every captured email
+func (dc *DevController) ClearMailbox(c echo.Context) error {
+	dc.mailbox.Clear()
+	return utils.SuccessResponse(c, "Mailbox cleared", nil)
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type EmailOutboxController struct {
+	outboxService *services.EmailOutboxService
+}
+
+// NewEmailOutboxController creates a new email outbox controller
+func NewEmailOutboxController(outboxService *services.EmailOutboxService) *EmailOutboxController {
+	return &EmailOutboxController{
+		outboxService: outboxService,
+	}
+}
+
+// ListEmails lists queued, sent and failed emails, optionally filtered by status (admin only)
+func (ec *EmailOutboxController) ListEmails(c echo.Context) error {
+	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+
+	ctx := c.Request().Context()
+	emails, total, err := ec.outboxService.ListEmails(ctx, c.QueryParam("status"), page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get emails", err)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	response := map[string]interface{}{
+		"emails": emails,
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	}
+
+	return utils.SuccessResponse(c, "Emails retrieved successfully", response)
+}
+
+// GetEmail gets an outbox email (admin only)
+func (ec *EmailOutboxController) GetEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+	email, err := ec.outboxService.GetEmail(ctx, c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get email", err)
+	}
+
+	return utils.SuccessResponse(c, "Email retrieved successfully", email)
+}
+
+// RetryEmail puts a failed email back in the queue (admin only)
+func (ec *EmailOutboxController) RetryEmail(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := ec.outboxService.RetryEmail(ctx, c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "only failed") {
+			return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to retry email", err)
+	}
+
+	return utils.SuccessResponse(c, "Email queued for retry", nil)
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExportController struct {
+	exportService *services.ExportService
+}
+
+// NewExportController creates a new export controller
+func NewExportController(exportService *services.ExportService) *ExportController {
+	return &ExportController{
+		exportService: exportService,
+	}
+}
+
+// ExportUsers streams the users matching the user search filters (admin only)
+func (ec *ExportController) ExportUsers(c echo.Context) error {
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	// from/to narrow the creation date like on the other exports
+	from, to, err := queryTimeRange(c)
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if from != nil {
+		filter.CreatedFrom = from
+	}
+	if to != nil {
+		filter.CreatedTo = to
+	}
+
+	export, err := ec.exportService.ExportUsers(filter, exportFormat(c), exportColumns(c))
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	return streamExport(c, "users", export)
+}
+
+// ExportBets streams bets filtered by user, game, status and time range (admin only)
+func (ec *ExportController) ExportBets(c echo.Context) error {
+	filter := &models.BetFilter{Status: c.QueryParam("status")}
+
+	var err error
+	if filter.UserID, err = objectIDQueryParam(c, "user_id"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.GameID, err = objectIDQueryParam(c, "game_id"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.From, filter.To, err = queryTimeRange(c); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	export, err := ec.exportService.ExportBets(filter, exportFormat(c), exportColumns(c))
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	return streamExport(c, "bets", export)
+}
+
+// ExportGameResults streams game results filtered by user, game, outcome and time range (admin only)
+func (ec *ExportController) ExportGameResults(c echo.Context) error {
+	filter := &models.GameResultFilter{}
+
+	var err error
+	if filter.UserID, err = objectIDQueryParam(c, "user_id"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.GameID, err = objectIDQueryParam(c, "game_id"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.Won, err = boolQueryParam(c, "won"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.From, filter.To, err = queryTimeRange(c); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	export, err := ec.exportService.ExportGameResults(filter, exportFormat(c), exportColumns(c))
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	return streamExport(c, "game-results", export)
+}
+
+// ExportCommissions streams referral commissions filtered by referrer, referred user, source and time range (admin only)
+func (ec *ExportController) ExportCommissions(c echo.Context) error {
+	filter := &models.CommissionFilter{Source: c.QueryParam("source")}
+
+	var err error
+	if filter.ReferrerID, err = objectIDQueryParam(c, "referrer_id"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.ReferredUserID, err = objectIDQueryParam(c, "referred_user_id"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.From, filter.To, err = queryTimeRange(c); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	export, err := ec.exportService.ExportCommissions(filter, exportFormat(c), exportColumns(c))
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	return streamExport(c, "commissions", export)
+}
+
+// streamExport sends an export as a file download. Once rows are on the wire a failure
+// can no longer become an error response, so it is logged and the download is cut short.
+func streamExport(c echo.Context, dataset string, export services.ExportFunc) error {
+	format := exportFormat(c)
+	contentType := "text/csv; charset=utf-8"
+	if format == models.ExportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	filename := fmt.Sprintf("%s-%s.%s", dataset, time.Now().UTC().Format("20060102-150405"), format)
+
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Response().WriteHeader(http.StatusOK)
+
+	if err := export(c.Request().Context(), c.Response()); err != nil {
+		fmt.Printf("Warning: %s export failed: %v\n", dataset, err)
+	}
+	return nil
+}
+
+// exportFormat gets the requested export format, CSV by default
+func exportFormat(c echo.Context) string {
+	if format := c.QueryParam("format"); format != "" {
+		return format
+	}
+	return models.ExportFormatCSV
+}
+
+// exportColumns gets the comma-separated list of requested columns
+func exportColumns(c echo.Context) []string {
+	var columns []string
+	for _, column := range strings.Split(c.QueryParam("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// queryTimeRange parses an optional from/to RFC 3339 time range
+func queryTimeRange(c echo.Context) (*time.Time, *time.Time, error) {
+	from, err := timeQueryParam(c, "from")
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := timeQueryParam(c, "to")
+	if err != nil {
+		return nil, nil, err
+	}
+	if from != nil && to != nil && from.After(*to) {
+		return nil, nil, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// objectIDQueryParam parses an optional ID query parameter
+func objectIDQueryParam(c echo.Context, name string) (*primitive.ObjectID, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	objectID, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a valid ID", name)
+	}
+	return &objectID, nil
+}
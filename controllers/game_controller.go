@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,13 +14,15 @@ import (
 )
 
 type GameController struct {
-	gameService *services.GameService
+	gameService        *services.GameService
+	playerStatsService *services.PlayerStatsService
 }
 
 // NewGameController creates a new game controller
-func NewGameController(gameService *services.GameService) *GameController {
+func NewGameController(gameService *services.GameService, playerStatsService *services.PlayerStatsService) *GameController {
 	return &GameController{
-		gameService: gameService,
+		gameService:        gameService,
+		playerStatsService: playerStatsService,
 	}
 }
 
@@ -122,7 +125,7 @@ func (gc *GameController) PlayGame(c echo.Context) error {
 	return utils.SuccessResponse(c, "Game played successfully", nil)
 }
 
-// GetGameHistory gets game history for the current user
+// GetGameHistory gets a page of game results for the current user
 func (gc *GameController) GetGameHistory(c echo.Context) error {
 	// Get user ID from JWT token
 	userID, err := utils.GetUserIDFromToken(c)
@@ -135,21 +138,125 @@ func (gc *GameController) GetGameHistory(c echo.Context) error {
 		return utils.BadRequestResponse(c, "Invalid user ID")
 	}
 
-	// Parse limit parameter
-	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
-	if limit <= 0 {
-		limit = 10
+	filter := &models.GameResultFilter{Outcome: c.QueryParam("outcome")}
+	if filter.BallID, err = intQueryParam(c, "ball"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.From, filter.To, err = queryTimeRange(c); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
 	}
 
 	ctx := c.Request().Context()
-	history, err := gc.gameService.GetGameHistory(ctx, objectID, limit)
+	history, err := gc.gameService.GetGameHistory(ctx, objectID, filter, c.QueryParam("cursor"), cursorLimit(c))
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to get game history", err)
 	}
 
 	return utils.SuccessResponse(c, "Game history retrieved successfully", history)
 }
 
+// GetBets gets a page of bets for the current user
+func (gc *GameController) GetBets(c echo.Context) error {
+	// Get user ID from JWT token
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID")
+	}
+
+	filter := &models.BetFilter{Status: c.QueryParam("outcome")}
+	if filter.BallID, err = intQueryParam(c, "ball"); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	if filter.From, filter.To, err = queryTimeRange(c); err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	bets, err := gc.gameService.GetBets(ctx, objectID, filter, c.QueryParam("cursor"), cursorLimit(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get bets", err)
+	}
+
+	return utils.SuccessResponse(c, "Bets retrieved successfully", bets)
+}
+
+// GetRounds gets a page of drawn rounds with their outcome and pool
+func (gc *GameController) GetRounds(c echo.Context) error {
+	ctx := c.Request().Context()
+	rounds, err := gc.gameService.GetRounds(ctx, c.QueryParam("cursor"), cursorLimit(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get rounds", err)
+	}
+
+	return utils.SuccessResponse(c, "Rounds retrieved successfully", rounds)
+}
+
+// GetRound gets the outcome of a drawn round, its bet distribution and the current user's bets in it
+func (gc *GameController) GetRound(c echo.Context) error {
+	// Get user ID from JWT token
+	userID, err := utils.GetUserIDFromToken(c)
+	if err != nil {
+		return utils.UnauthorizedResponse(c, "Invalid token")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID")
+	}
+
+	ctx := c.Request().Context()
+	round, err := gc.gameService.GetRound(ctx, objectID, c.Param("id"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid"):
+			return utils.BadRequestResponse(c, err.Error())
+		case strings.Contains(err.Error(), "not found"):
+			return utils.NotFoundResponse(c, err.Error())
+		case strings.Contains(err.Error(), "not completed"):
+			return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get round", err)
+	}
+
+	return utils.SuccessResponse(c, "Round retrieved successfully", round)
+}
+
+// cursorLimit gets the page size of a cursor-paginated listing
+func cursorLimit(c echo.Context) int64 {
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return limit
+}
+
+// intQueryParam parses an optional integer query parameter
+func intQueryParam(c echo.Context, name string) (*int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", name)
+	}
+	return &parsed, nil
+}
+
 // GetGameStats gets overall game statistics
 func (gc *GameController) GetGameStats(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -161,7 +268,7 @@ func (gc *GameController) GetGameStats(c echo.Context) error {
 	return utils.SuccessResponse(c, "Game statistics retrieved successfully", stats)
 }
 
-// GetUserGameStats gets game statistics for the current user
+// GetUserGameStats gets game statistics for the current user, optionally limited to a from/to range
 func (gc *GameController) GetUserGameStats(c echo.Context) error {
 	// Get user ID from JWT token
 	userID, err := utils.GetUserIDFromToken(c)
@@ -174,23 +281,29 @@ func (gc *GameController) GetUserGameStats(c echo.Context) error {
 		return utils.BadRequestResponse(c, "Invalid user ID")
 	}
 
+	from, err := timeQueryParam(c, "from")
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	to, err := timeQueryParam(c, "to")
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
 	ctx := c.Request().Context()
-	stats, err := gc.gameService.GetUserGameStats(ctx, objectID)
+	stats, err := gc.playerStatsService.GetPlayerStats(ctx, objectID, from, to)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to get user game statistics", err)
 	}
 
 	return utils.SuccessResponse(c, "User game statistics retrieved successfully", stats)
 }
 
-// SimulateOtherPlayers simulates other players (admin only)
+// SimulateOtherPlayers simulates other players (requires games.manage)
 func (gc *GameController) SimulateOtherPlayers(c echo.Context) error {
-	// Check if user is admin
-	role, err := utils.GetUserRoleFromToken(c)
-	if err != nil || role != "admin" {
-		return utils.ForbiddenResponse(c, "Admin access required")
-	}
-
 	// Get game ID from URL parameter
 	gameID := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(gameID)
@@ -229,14 +342,8 @@ func (gc *GameController) GetAvailableBaskets(c echo.Context) error {
 	return utils.SuccessResponse(c, "Available baskets retrieved successfully", baskets)
 }
 
-// GetHouseWallet gets current house wallet state (admin only)
+// GetHouseWallet gets current house wallet state (requires wallet.manage)
 func (gc *GameController) GetHouseWallet(c echo.Context) error {
-	// Check if user is admin
-	role, err := utils.GetUserRoleFromToken(c)
-	if err != nil || role != "admin" {
-		return utils.ForbiddenResponse(c, "Admin access required")
-	}
-
 	ctx := c.Request().Context()
 	houseWallet, err := gc.gameService.GetHouseWallet(ctx)
 	if err != nil {
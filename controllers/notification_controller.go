@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationController struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationController creates a new notification controller
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+// GetNotifications lists the current user's notifications with their unread count
+func (nc *NotificationController) GetNotifications(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	unreadOnly, _ := strconv.ParseBool(c.QueryParam("unread"))
+
+	ctx := c.Request().Context()
+	notifications, total, unread, err := nc.notificationService.GetNotifications(ctx, objectID, c.QueryParam("category"), unreadOnly, page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get notifications", err)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	response := map[string]interface{}{
+		"notifications": notifications,
+		"unread_count":  unread,
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	}
+
+	return utils.SuccessResponse(c, "Notifications retrieved successfully", response)
+}
+
+// GetUnreadCount gets the number of unread notifications for the current user
+func (nc *NotificationController) GetUnreadCount(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	ctx := c.Request().Context()
+	unread, err := nc.notificationService.GetUnreadCount(ctx, objectID)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to count notifications", err)
+	}
+
+	return utils.SuccessResponse(c, "Unread count retrieved successfully", map[string]interface{}{
+		"unread_count": unread,
+	})
+}
+
+// MarkRead marks one of the current user's notifications as read
+func (nc *NotificationController) MarkRead(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	ctx := c.Request().Context()
+	if err := nc.notificationService.MarkRead(ctx, objectID, c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, "Notification not found")
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to mark notification as read", err)
+	}
+
+	return utils.SuccessResponse(c, "Notification marked as read", nil)
+}
+
+// MarkAllRead marks the given notifications, or all of them, as read for the current user
+func (nc *NotificationController) MarkAllRead(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	var req models.MarkNotificationsReadRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	updated, err := nc.notificationService.MarkAllRead(ctx, objectID, req.IDs)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to mark notifications as read", err)
+	}
+
+	return utils.SuccessResponse(c, "Notifications marked as read", map[string]interface{}{
+		"updated": updated,
+	})
+}
+
+// GetPreferences gets which notification categories the current user receives by email
+func (nc *NotificationController) GetPreferences(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	ctx := c.Request().Context()
+	preferences, err := nc.notificationService.GetPreferences(ctx, objectID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get notification preferences", err)
+	}
+
+	return utils.SuccessResponse(c, "Notification preferences retrieved successfully", map[string]interface{}{
+		"email": preferences,
+	})
+}
+
+// UpdatePreferences changes which notification categories the current user receives by email
+func (nc *NotificationController) UpdatePreferences(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	var req models.NotificationPreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	preferences, err := nc.notificationService.UpdatePreferences(ctx, objectID, req.Email)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to update notification preferences", err)
+	}
+
+	return utils.SuccessResponse(c, "Notification preferences updated successfully", map[string]interface{}{
+		"email": preferences,
+	})
+}
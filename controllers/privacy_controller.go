@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PrivacyController struct {
+	privacyService *services.PrivacyService
+}
+
+// NewPrivacyController creates a new privacy controller
+func NewPrivacyController(privacyService *services.PrivacyService) *PrivacyController {
+	return &PrivacyController{
+		privacyService: privacyService,
+	}
+}
+
+// ExportData returns everything stored about the current user as JSON, or as a ZIP archive with format=zip
+func (pc *PrivacyController) ExportData(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "zip" {
+		return utils.BadRequestResponse(c, "format must be one of: json zip")
+	}
+
+	ctx := c.Request().Context()
+	export, err := pc.privacyService.ExportData(ctx, objectID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to export data", err)
+	}
+
+	if format != "zip" {
+		return utils.SuccessResponse(c, "Data exported successfully", export)
+	}
+
+	filename := fmt.Sprintf("bucketball-export-%s.zip", export.GeneratedAt.Format("20060102-150405"))
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Response().WriteHeader(http.StatusOK)
+	return pc.privacyService.WriteExportArchive(c.Response(), export)
+}
+
+// DeleteAccount erases the current user's personal data after confirming their password
+func (pc *PrivacyController) DeleteAccount(c echo.Context) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	if err := pc.privacyService.DeleteAccount(ctx, objectID, req.Password); err != nil {
+		if strings.Contains(err.Error(), "incorrect") {
+			return utils.UnauthorizedResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "already deleted") {
+			return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to delete account", err)
+	}
+
+	return utils.SuccessResponse(c, "Account deleted successfully", nil)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReferralController struct {
+	referralService *services.ReferralService
+}
+
+// NewReferralController creates a new referral controller
+func NewReferralController(referralService *services.ReferralService) *ReferralController {
+	return &ReferralController{
+		referralService: referralService,
+	}
+}
+
+// GetReferralDashboard gets referred users, commissions and earnings for the current user
+func (rc *ReferralController) GetReferralDashboard(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", nil)
+	}
+
+	// Parse pagination and grouping parameters
+	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	granularity := c.QueryParam("granularity")
+	if granularity != "" && granularity != "day" && granularity != "month" {
+		return utils.BadRequestResponse(c, "granularity must be one of: day month")
+	}
+
+	ctx := c.Request().Context()
+	dashboard, err := rc.referralService.GetReferralDashboard(ctx, objectID, page, limit, granularity)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to get referral dashboard", err)
+	}
+
+	return utils.SuccessResponse(c, "Referral dashboard retrieved successfully", dashboard)
+}
+
+// ListPrograms lists every referral program version (admin only)
+func (rc *ReferralController) ListPrograms(c echo.Context) error {
+	ctx := c.Request().Context()
+	programs, err := rc.referralService.ListPrograms(ctx)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to get referral programs", err)
+	}
+
+	return utils.SuccessResponse(c, "Referral programs retrieved successfully", programs)
+}
+
+// GetActiveProgram gets the referral program currently in force (admin only)
+func (rc *ReferralController) GetActiveProgram(c echo.Context) error {
+	ctx := c.Request().Context()
+	program, err := rc.referralService.GetActiveProgram(ctx)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to get active referral program", err)
+	}
+
+	return utils.SuccessResponse(c, "Active referral program retrieved successfully", program)
+}
+
+// PublishProgram publishes a new referral program version (admin only)
+func (rc *ReferralController) PublishProgram(c echo.Context) error {
+	adminID, err := primitive.ObjectIDFromHex(c.Get("user_id").(string))
+	if err != nil {
+		return utils.BadRequestResponse(c, "Invalid user ID")
+	}
+
+	var req models.ReferralProgramRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return utils.ValidationErrorResponse(c, "Referral program validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	program, err := rc.referralService.PublishProgram(ctx, adminID, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to publish referral program", err)
+	}
+
+	return utils.SuccessResponse(c, "Referral program published successfully", program)
+}
+
+// ActivateProgram makes an earlier referral program version active again (admin only)
+func (rc *ReferralController) ActivateProgram(c echo.Context) error {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		return utils.BadRequestResponse(c, "Invalid program version")
+	}
+
+	ctx := c.Request().Context()
+	program, err := rc.referralService.ActivateProgram(ctx, version)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to activate referral program", err)
+	}
+
+	return utils.SuccessResponse(c, "Referral program activated successfully", program)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type RoleController struct {
+	roleService *services.RoleService
+}
+
+// NewRoleController creates a new role controller
+func NewRoleController(roleService *services.RoleService) *RoleController {
+	return &RoleController{
+		roleService: roleService,
+	}
+}
+
+// ListPermissions lists every permission that can be granted
+func (rc *RoleController) ListPermissions(c echo.Context) error {
+	return utils.SuccessResponse(c, "Permissions retrieved successfully", models.AllPermissions)
+}
+
+// ListRoles lists all roles
+func (rc *RoleController) ListRoles(c echo.Context) error {
+	ctx := c.Request().Context()
+	roles, err := rc.roleService.ListRoles(ctx)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to get roles", err)
+	}
+
+	return utils.SuccessResponse(c, "Roles retrieved successfully", roles)
+}
+
+// CreateRole creates a custom role
+func (rc *RoleController) CreateRole(c echo.Context) error {
+	var req models.CreateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	role, err := rc.roleService.CreateRole(ctx, &req)
+	if err != nil {
+		return roleErrorResponse(c, err, "Failed to create role")
+	}
+
+	return utils.SuccessResponse(c, "Role created successfully", role)
+}
+
+// UpdateRole updates a role's description and permissions
+func (rc *RoleController) UpdateRole(c echo.Context) error {
+	var req models.UpdateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	role, err := rc.roleService.UpdateRole(ctx, c.Param("name"), &req)
+	if err != nil {
+		return roleErrorResponse(c, err, "Failed to update role")
+	}
+
+	return utils.SuccessResponse(c, "Role updated successfully", role)
+}
+
+// DeleteRole deletes a custom role
+func (rc *RoleController) DeleteRole(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := rc.roleService.DeleteRole(ctx, c.Param("name")); err != nil {
+		return roleErrorResponse(c, err, "Failed to delete role")
+	}
+
+	return utils.SuccessResponse(c, "Role deleted successfully", nil)
+}
+
+// AssignRole changes a user's role
+func (rc *RoleController) AssignRole(c echo.Context) error {
+	var req models.AssignRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	actorRole := c.Get("user_role").(string)
+	ctx := c.Request().Context()
+	if err := rc.roleService.AssignRole(ctx, actorRole, c.Param("id"), req.Role); err != nil {
+		return roleErrorResponse(c, err, "Failed to assign role")
+	}
+
+	return utils.SuccessResponse(c, "Role assigned successfully", nil)
+}
+
+// roleErrorResponse maps role service errors to HTTP responses
+func roleErrorResponse(c echo.Context, err error, message string) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return utils.NotFoundResponse(c, err.Error())
+	case strings.Contains(err.Error(), "only a superadmin"):
+		return utils.ForbiddenResponse(c, err.Error())
+	case strings.Contains(err.Error(), "already exists"),
+		strings.Contains(err.Error(), "still assigned"):
+		return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+	case strings.Contains(err.Error(), "unknown permission"),
+		strings.Contains(err.Error(), "invalid user ID"),
+		strings.Contains(err.Error(), "cannot be"):
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	return utils.InternalServerErrorResponse(c, message, err)
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type SessionController struct {
+	sessionService *services.SessionService
+}
+
+// NewSessionController creates a new session controller
+func NewSessionController(sessionService *services.SessionService) *SessionController {
+	return &SessionController{
+		sessionService: sessionService,
+	}
+}
+
+// GetSessions lists the current user's active sessions
+func (sc *SessionController) GetSessions(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	currentSessionID, _ := c.Get("session_id").(string)
+	ctx := c.Request().Context()
+
+	sessions, err := sc.sessionService.ListSessions(ctx, userID, currentSessionID)
+	if err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to get sessions", err)
+	}
+
+	return utils.SuccessResponse(c, "Sessions retrieved successfully", sessions)
+}
+
+// RevokeSession signs the current user out of one session
+func (sc *SessionController) RevokeSession(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	sessionID := c.Param("id")
+	ctx := c.Request().Context()
+
+	if err := sc.sessionService.RevokeSession(ctx, userID, sessionID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, "Session not found")
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to revoke session", err)
+	}
+
+	return utils.SuccessResponse(c, "Session revoked successfully", nil)
+}
+
+// RevokeAllSessions signs the current user out of every session, including this one
+func (sc *SessionController) RevokeAllSessions(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+	ctx := c.Request().Context()
+
+	if err := sc.sessionService.RevokeAllSessions(ctx, userID); err != nil {
+		return utils.InternalServerErrorResponse(c, "Failed to revoke sessions", err)
+	}
+
+	return utils.SuccessResponse(c, "Logged out of all sessions successfully", nil)
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type TwoFactorController struct {
+	authService *services.AuthService
+}
+
+// NewTwoFactorController creates a new two-factor controller
+func NewTwoFactorController(authService *services.AuthService) *TwoFactorController {
+	return &TwoFactorController{
+		authService: authService,
+	}
+}
+
+// BeginSetup generates a TOTP secret for the current user
+func (tc *TwoFactorController) BeginSetup(c echo.Context) error {
+	userID := c.Get("user_id").(string)
+
+	ctx := c.Request().Context()
+	setup, err := tc.authService.BeginTwoFactorSetup(ctx, userID)
+	if err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to start two-factor setup")
+	}
+
+	return utils.SuccessResponse(c, "Scan the secret with your authenticator app and confirm with a code", setup)
+}
+
+// ConfirmSetup enables 2FA for the current user and returns recovery codes
+func (tc *TwoFactorController) ConfirmSetup(c echo.Context) error {
+	var req models.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	userID := c.Get("user_id").(string)
+	ctx := c.Request().Context()
+	codes, err := tc.authService.ConfirmTwoFactor(ctx, userID, req.Code)
+	if err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to enable two-factor authentication")
+	}
+
+	return utils.SuccessResponse(c, "Two-factor authentication enabled", models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable turns off 2FA for the current user
+func (tc *TwoFactorController) Disable(c echo.Context) error {
+	var req models.TwoFactorDisableRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	userID := c.Get("user_id").(string)
+	ctx := c.Request().Context()
+	if err := tc.authService.DisableTwoFactor(ctx, userID, req.Password, req.Code); err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to disable two-factor authentication")
+	}
+
+	return utils.SuccessResponse(c, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (tc *TwoFactorController) RegenerateRecoveryCodes(c echo.Context) error {
+	var req models.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	userID := c.Get("user_id").(string)
+	ctx := c.Request().Context()
+	codes, err := tc.authService.RegenerateRecoveryCodes(ctx, userID, req.Code)
+	if err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to regenerate recovery codes")
+	}
+
+	return utils.SuccessResponse(c, "Recovery codes regenerated", models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// BeginChallengeSetup generates a TOTP secret for an account that must enrol 2FA to finish logging in
+func (tc *TwoFactorController) BeginChallengeSetup(c echo.Context) error {
+	var req models.TwoFactorChallengeRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	setup, err := tc.authService.BeginChallengeSetup(ctx, req.ChallengeToken)
+	if err != nil {
+		return twoFactorErrorResponse(c, err, "Failed to start two-factor setup")
+	}
+
+	return utils.SuccessResponse(c, "Scan the secret with your authenticator app and verify with a code", setup)
+}
+
+// VerifyLogin completes a login challenge with a TOTP or recovery code
+func (tc *TwoFactorController) VerifyLogin(c echo.Context) error {
+	var req models.TwoFactorLoginRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	client := utils.GetClientInfo(c)
+	user, tokens, recoveryCodes, err := tc.authService.VerifyTwoFactorLogin(ctx, req.ChallengeToken, req.Code, client)
+	if err != nil {
+		if strings.Contains(err.Error(), "too many login attempts") {
+			return utils.ErrorResponse(c, http.StatusTooManyRequests, err.Error(), nil)
+		}
+		return utils.UnauthorizedResponse(c, err.Error())
+	}
+
+	// Return response
+	userResponse := models.UserResponse{
+		ID:               user.ID,
+		Email:            user.Email,
+		Username:         user.Username,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		ProfilePic:       user.ProfilePic,
+		DOB:              user.DOB,
+		PhoneNumber:      user.PhoneNumber,
+		Location:         user.Location,
+		Balance:          user.Balance,
+		Withdraw:         user.Withdraw,
+		Role:             user.Role,
+		IsActive:         user.IsActive,
+		IsEmailVerified:  user.IsEmailVerified,
+		ReferralCode:     user.ReferralCode,
+		ReferredBy:       user.ReferredBy,
+		ReferralEarnings: user.ReferralEarnings,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		Language:         user.Language,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+	}
+
+	authResponse := models.AuthResponse{
+		Token:         tokens.AccessToken,
+		RefreshToken:  tokens.RefreshToken,
+		ExpiresIn:     tokens.ExpiresIn,
+		User:          userResponse,
+		RecoveryCodes: recoveryCodes,
+	}
+
+	return utils.SuccessResponse(c, "Login successful", authResponse)
+}
+
+// twoFactorErrorResponse maps two-factor service errors to HTTP responses
+func twoFactorErrorResponse(c echo.Context, err error, message string) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return utils.NotFoundResponse(c, err.Error())
+	case strings.Contains(err.Error(), "invalid password"),
+		strings.Contains(err.Error(), "invalid two-factor code"),
+		strings.Contains(err.Error(), "invalid or expired challenge"):
+		return utils.UnauthorizedResponse(c, err.Error())
+	case strings.Contains(err.Error(), "mandatory"):
+		return utils.ErrorResponse(c, http.StatusForbidden, err.Error(), nil)
+	case strings.Contains(err.Error(), "already enabled"),
+		strings.Contains(err.Error(), "not enabled"),
+		strings.Contains(err.Error(), "no two-factor setup"):
+		return utils.BadRequestResponse(c, err.Error())
+	}
+	return utils.InternalServerErrorResponse(c, message, err)
+}
@@ -1,14 +1,18 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/HSouheil/bucketball_backend/services"
 	"github.com/HSouheil/bucketball_backend/utils"
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserController struct {
@@ -22,20 +26,28 @@ func NewUserController(userService *services.UserService) *UserController {
 	}
 }
 
-// GetUsers gets a list of users with pagination
+// GetUsers searches users with filters, sorting and pagination
 func (uc *UserController) GetUsers(c echo.Context) error {
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		return utils.BadRequestResponse(c, err.Error())
+	}
+
 	// Parse pagination parameters
 	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 64)
 	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
 
 	ctx := c.Request().Context()
-	users, total, err := uc.userService.GetUsers(ctx, page, limit)
+	users, total, err := uc.userService.GetUsers(ctx, filter, page, limit)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to get users", err)
 	}
 
 	// Convert to response format
-	var userResponses []models.UserResponse
+	userResponses := []models.UserResponse{}
 	for _, user := range users {
 		userResponses = append(userResponses, models.UserResponse{
 			ID:              user.ID,
@@ -55,6 +67,8 @@ func (uc *UserController) GetUsers(c echo.Context) error {
 			ReferralCode:    user.ReferralCode,
 			ReferredBy:      user.ReferredBy,
 			ReferralEarnings: user.ReferralEarnings,
+			TwoFactorEnabled: user.TwoFactorEnabled,
+			Language:         user.Language,
 			CreatedAt:       user.CreatedAt,
 			UpdatedAt:       user.UpdatedAt,
 		})
@@ -63,7 +77,7 @@ func (uc *UserController) GetUsers(c echo.Context) error {
 	if page < 1 {
 		page = 1
 	}
-	if limit < 1 {
+	if limit < 1 || limit > 100 {
 		limit = 10
 	}
 
@@ -80,6 +94,97 @@ func (uc *UserController) GetUsers(c echo.Context) error {
 	return utils.SuccessResponse(c, "Users retrieved successfully", response)
 }
 
+// parseUserFilter reads the user search filters and sort order from the query string
+func parseUserFilter(c echo.Context) (*models.UserFilter, error) {
+	filter := &models.UserFilter{
+		Search:   strings.TrimSpace(c.QueryParam("q")),
+		Role:     c.QueryParam("role"),
+		Country:  c.QueryParam("country"),
+		SortBy:   c.QueryParam("sort"),
+		SortDesc: true,
+	}
+
+	switch c.QueryParam("order") {
+	case "", "desc":
+	case "asc":
+		filter.SortDesc = false
+	default:
+		return nil, errors.New("order must be one of: asc desc")
+	}
+
+	var err error
+	if filter.IsActive, err = boolQueryParam(c, "is_active"); err != nil {
+		return nil, err
+	}
+	if filter.IsEmailVerified, err = boolQueryParam(c, "is_email_verified"); err != nil {
+		return nil, err
+	}
+	if filter.IsDeleted, err = boolQueryParam(c, "deleted"); err != nil {
+		return nil, err
+	}
+	if filter.MinBalance, err = floatQueryParam(c, "min_balance"); err != nil {
+		return nil, err
+	}
+	if filter.MaxBalance, err = floatQueryParam(c, "max_balance"); err != nil {
+		return nil, err
+	}
+	if filter.CreatedFrom, err = timeQueryParam(c, "created_from"); err != nil {
+		return nil, err
+	}
+	if filter.CreatedTo, err = timeQueryParam(c, "created_to"); err != nil {
+		return nil, err
+	}
+
+	if referredBy := c.QueryParam("referred_by"); referredBy != "" {
+		objectID, err := primitive.ObjectIDFromHex(referredBy)
+		if err != nil {
+			return nil, errors.New("referred_by must be a user ID")
+		}
+		filter.ReferredBy = &objectID
+	}
+
+	return filter, nil
+}
+
+// boolQueryParam parses an optional boolean query parameter
+func boolQueryParam(c echo.Context, name string) (*bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", name)
+	}
+	return &parsed, nil
+}
+
+// floatQueryParam parses an optional numeric query parameter
+func floatQueryParam(c echo.Context, name string) (*float64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", name)
+	}
+	return &parsed, nil
+}
+
+// timeQueryParam parses an optional RFC 3339 timestamp query parameter
+func timeQueryParam(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &parsed, nil
+}
+
 // GetUser gets a user by ID
 func (uc *UserController) GetUser(c echo.Context) error {
 	userID := c.Param("id")
@@ -108,6 +213,8 @@ func (uc *UserController) GetUser(c echo.Context) error {
 		ReferralCode:    user.ReferralCode,
 		ReferredBy:      user.ReferredBy,
 		ReferralEarnings: user.ReferralEarnings,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		Language:         user.Language,
 		CreatedAt:       user.CreatedAt,
 		UpdatedAt:       user.UpdatedAt,
 	}
@@ -119,7 +226,7 @@ func (uc *UserController) GetUser(c echo.Context) error {
 func (uc *UserController) UpdateUser(c echo.Context) error {
 	userID := c.Param("id")
 
-	var req models.UpdateUserRequest
+	var req models.AdminUpdateUserRequest
 	if err := c.Bind(&req); err != nil {
 		return utils.ValidationErrorResponse(c, "Invalid request data", err)
 	}
@@ -145,7 +252,7 @@ func (uc *UserController) UpdateUser(c echo.Context) error {
 	return utils.SuccessResponse(c, "User updated successfully", nil)
 }
 
-// DeleteUser deletes a user (admin only)
+// DeleteUser erases a user's personal data (admin only)
 func (uc *UserController) DeleteUser(c echo.Context) error {
 	userID := c.Param("id")
 	ctx := c.Request().Context()
@@ -154,6 +261,9 @@ func (uc *UserController) DeleteUser(c echo.Context) error {
 		if strings.Contains(err.Error(), "not found") {
 			return utils.NotFoundResponse(c, err.Error())
 		}
+		if strings.Contains(err.Error(), "already deleted") {
+			return utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		}
 		return utils.InternalServerErrorResponse(c, "Failed to delete user", err)
 	}
 
@@ -180,3 +290,91 @@ func (uc *UserController) ToggleUserStatus(c echo.Context) error {
 
 	return utils.SuccessResponse(c, "User "+status+" successfully", nil)
 }
+
+// AdjustBalance credits or debits a user's balance with a recorded reason (admin only)
+func (uc *UserController) AdjustBalance(c echo.Context) error {
+	userID := c.Param("id")
+	adminID := c.Get("user_id").(string)
+
+	var req models.AdminBalanceAdjustmentRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request data", err)
+	}
+
+	if err := utils.ValidateStruct(&req); err != nil {
+		return utils.ValidationErrorResponse(c, "Validation failed", err)
+	}
+
+	ctx := c.Request().Context()
+	transaction, err := uc.userService.AdjustBalance(ctx, adminID, userID, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "insufficient balance") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to adjust balance", err)
+	}
+
+	return utils.SuccessResponse(c, "Balance adjusted successfully", transaction)
+}
+
+// GetUserTransactions gets a user's transactions (admin only)
+func (uc *UserController) GetUserTransactions(c echo.Context) error {
+	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+
+	ctx := c.Request().Context()
+	transactions, total, err := uc.userService.GetUserTransactions(ctx, c.Param("id"), page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get transactions", err)
+	}
+
+	return utils.SuccessResponse(c, "Transactions retrieved successfully", transactionPage(transactions, total, page, limit))
+}
+
+// GetBalanceAdjustments gets the balance adjustments made by an admin, the current one by default (admin only)
+func (uc *UserController) GetBalanceAdjustments(c echo.Context) error {
+	adminID := c.QueryParam("admin_id")
+	if adminID == "" {
+		adminID = c.Get("user_id").(string)
+	}
+
+	page, _ := strconv.ParseInt(c.QueryParam("page"), 10, 64)
+	limit, _ := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+
+	ctx := c.Request().Context()
+	transactions, total, err := uc.userService.GetAdminAdjustments(ctx, adminID, page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return utils.BadRequestResponse(c, err.Error())
+		}
+		return utils.InternalServerErrorResponse(c, "Failed to get balance adjustments", err)
+	}
+
+	return utils.SuccessResponse(c, "Balance adjustments retrieved successfully", transactionPage(transactions, total, page, limit))
+}
+
+// transactionPage wraps a page of transactions with pagination info
+func transactionPage(transactions []models.Transaction, total, page, limit int64) map[string]interface{} {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	return map[string]interface{}{
+		"transactions": transactions,
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	}
+}
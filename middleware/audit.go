@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/services"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// AuditMiddleware attaches the authenticated actor, IP and request ID to the request
+// context so services can record audit entries. Must run after AuthMiddleware.
+func AuditMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			client := utils.GetClientInfo(c)
+			actor := &models.AuditActor{
+				IP:        client.IP,
+				UserAgent: client.UserAgent,
+				RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+			}
+			actor.UserID, _ = c.Get("user_id").(string)
+			actor.Email, _ = c.Get("user_email").(string)
+			actor.Role, _ = c.Get("user_role").(string)
+
+			ctx := services.WithAuditActor(c.Request().Context(), actor)
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
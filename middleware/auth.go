@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"strings"
+	"time"
 
 	"github.com/HSouheil/bucketball_backend/repositories"
 	"github.com/HSouheil/bucketball_backend/security"
+	"github.com/HSouheil/bucketball_backend/services"
 	"github.com/HSouheil/bucketball_backend/utils"
 	"github.com/labstack/echo/v4"
 )
@@ -39,23 +41,37 @@ func AuthMiddleware(authRepo *repositories.AuthRepository) echo.MiddlewareFunc {
 				return utils.UnauthorizedResponse(c, "Invalid token")
 			}
 
+			// Check if all of the user's sessions were revoked after this token was issued
+			revoked, err := isSessionRevoked(c, authRepo, claims)
+			if err != nil {
+				return utils.InternalServerErrorResponse(c, "Failed to check token status", err)
+			}
+			if revoked {
+				return utils.UnauthorizedResponse(c, "Token has been revoked")
+			}
+
 			// Set user info in context
 			c.Set("user_id", claims.UserID)
 			c.Set("user_email", claims.Email)
 			c.Set("user_username", claims.Username)
 			c.Set("user_role", claims.Role)
+			c.Set("session_id", claims.FamilyID)
 
 			return next(c)
 		}
 	}
 }
 
-// AdminMiddleware checks if user is admin
-func AdminMiddleware() echo.MiddlewareFunc {
+// AdminMiddleware checks if user's role gives access to the admin API
+func AdminMiddleware(roleService *services.RoleService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			role := c.Get("user_role").(string)
-			if role != "admin" {
+			role, _ := c.Get("user_role").(string)
+			isStaff, err := roleService.IsStaffRole(c.Request().Context(), role)
+			if err != nil {
+				return utils.InternalServerErrorResponse(c, "Failed to check permissions", err)
+			}
+			if !isStaff {
 				return utils.ForbiddenResponse(c, "Admin access required")
 			}
 			return next(c)
@@ -63,6 +79,23 @@ func AdminMiddleware() echo.MiddlewareFunc {
 	}
 }
 
+// RequirePermission checks if user's role grants the given permission
+func RequirePermission(roleService *services.RoleService, permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, _ := c.Get("user_role").(string)
+			allowed, err := roleService.HasPermission(c.Request().Context(), role, permission)
+			if err != nil {
+				return utils.InternalServerErrorResponse(c, "Failed to check permissions", err)
+			}
+			if !allowed {
+				return utils.ForbiddenResponse(c, "Missing permission: "+permission)
+			}
+			return next(c)
+		}
+	}
+}
+
 // OptionalAuthMiddleware validates JWT token if present
 func OptionalAuthMiddleware(authRepo *repositories.AuthRepository) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -77,6 +110,9 @@ func OptionalAuthMiddleware(authRepo *repositories.AuthRepository) echo.Middlewa
 					if err == nil && !isBlacklisted {
 						// Validate token
 						if claims, err := security.ValidateToken(tokenString); err == nil {
+							if revoked, err := isSessionRevoked(c, authRepo, claims); err != nil || revoked {
+								return next(c)
+							}
 							// Set user info in context
 							c.Set("user_id", claims.UserID)
 							c.Set("user_email", claims.Email)
@@ -90,3 +126,43 @@ func OptionalAuthMiddleware(authRepo *repositories.AuthRepository) echo.Middlewa
 		}
 	}
 }
+
+// sessionTouchInterval limits how often a session's last-seen time is written
+const sessionTouchInterval = time.Minute
+
+// isSessionRevoked checks if the token's session was revoked or the token was issued
+// before the user's sessions were revoked. Live sessions get their last-seen time updated.
+func isSessionRevoked(c echo.Context, authRepo *repositories.AuthRepository, claims *security.Claims) (bool, error) {
+	ctx := c.Request().Context()
+	if claims.FamilyID != "" {
+		session, err := authRepo.GetSession(ctx, claims.FamilyID)
+		if err != nil {
+			return false, err
+		}
+		if session == nil || session.UserID != claims.UserID {
+			return true, nil
+		}
+
+		if time.Since(session.LastSeenAt) > sessionTouchInterval {
+			session.LastSeenAt = time.Now()
+			session.IP = c.RealIP()
+			authRepo.TouchSession(ctx, session)
+		}
+	}
+
+	revokedAt, err := authRepo.GetSessionsRevokedAt(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if revokedAt.IsZero() {
+		return false, nil
+	}
+	if claims.IssuedAtMillis != 0 {
+		return claims.IssuedAtMillis <= revokedAt.UnixMilli(), nil
+	}
+	// Tokens issued before iat_ms existed only carry the issue second
+	if claims.IssuedAt == nil {
+		return false, nil
+	}
+	return !claims.IssuedAt.Time.After(revokedAt), nil
+}
@@ -2,87 +2,50 @@ package middleware
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"net/http"
-	"time"
+	"strconv"
 
-	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/HSouheil/bucketball_backend/config"
+	"github.com/HSouheil/bucketball_backend/services"
 	"github.com/labstack/echo/v4"
 )
 
-// RateLimitMiddleware implements rate limiting using Redis
-func RateLimitMiddleware(authRepo *repositories.AuthRepository, requests int, window time.Duration) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			clientIP := c.RealIP()
-			key := fmt.Sprintf("rate_limit:%s", clientIP)
-
-			ctx := c.Request().Context()
-
-			// Get current count
-			count, err := authRepo.GetRedis().Get(ctx, key).Int()
-			if err != nil && err.Error() != "redis: nil" {
-				// If there's an error (not nil), continue without rate limiting
-				return next(c)
-			}
-
-			if count >= requests {
-				return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
-					"success": false,
-					"message": "Rate limit exceeded",
-					"error":   fmt.Sprintf("Maximum %d requests per %v", requests, window),
-				})
-			}
-
-			// Increment counter
-			pipe := authRepo.GetRedis().Pipeline()
-			pipe.Incr(ctx, key)
-			pipe.Expire(ctx, key, window)
-			_, err = pipe.Exec(ctx)
-			if err != nil {
-				// If there's an error, continue without rate limiting
-				return next(c)
-			}
-
-			return next(c)
-		}
+// RateLimit limits requests with a named policy from the rate limit config and reports the
+// limit in X-RateLimit-* headers, plus Retry-After once it is exceeded
+func RateLimit(limiter *services.RequestLimiter, name string) echo.MiddlewareFunc {
+	policy, ok := limiter.Policy(name)
+	if !ok {
+		log.Fatalf("Unknown rate limit policy %q", name)
 	}
-}
 
-// AuthRateLimitMiddleware implements rate limiting for authenticated users
-func AuthRateLimitMiddleware(authRepo *repositories.AuthRepository, requests int, window time.Duration) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			userID := c.Get("user_id")
-			if userID == nil {
-				return next(c)
+			client := "ip:" + c.RealIP()
+			if policy.Scope == config.RateLimitByUser {
+				if userID, ok := c.Get("user_id").(string); ok && userID != "" {
+					client = "user:" + userID
+				}
 			}
 
-			key := fmt.Sprintf("auth_rate_limit:%s", userID)
-			ctx := c.Request().Context()
+			decision := limiter.Allow(c.Request().Context(), name, policy, client)
+			resetSeconds := strconv.Itoa(int(math.Ceil(decision.Reset.Seconds())))
 
-			// Get current count
-			count, err := authRepo.GetRedis().Get(ctx, key).Int()
-			if err != nil && err.Error() != "redis: nil" {
-				return next(c)
-			}
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("X-RateLimit-Reset", resetSeconds)
 
-			if count >= requests {
+			if !decision.Allowed {
+				header.Set("Retry-After", resetSeconds)
 				return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
 					"success": false,
 					"message": "Rate limit exceeded",
-					"error":   fmt.Sprintf("Maximum %d requests per %v", requests, window),
+					"error":   fmt.Sprintf("Maximum %d requests per %v", policy.Requests, policy.Window),
 				})
 			}
 
-			// Increment counter
-			pipe := authRepo.GetRedis().Pipeline()
-			pipe.Incr(ctx, key)
-			pipe.Expire(ctx, key, window)
-			_, err = pipe.Exec(ctx)
-			if err != nil {
-				return next(c)
-			}
-
 			return next(c)
 		}
 	}
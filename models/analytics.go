@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Analytics granularities
+const (
+	AnalyticsGranularityHour  = "hour"
+	AnalyticsGranularityDay   = "day"
+	AnalyticsGranularityWeek  = "week" // weeks start on Monday
+	AnalyticsGranularityMonth = "month"
+)
+
+// AnalyticsBucket represents the operator metrics of one time bucket (UTC).
+// GGR is wagered minus payouts; net revenue is GGR minus the admin skim and referral commissions.
+type AnalyticsBucket struct {
+	Start               time.Time `json:"start" bson:"_id"`
+	Rounds              int64     `json:"rounds" bson:"rounds"`
+	Bets                int64     `json:"bets" bson:"bets"`
+	Wagered             float64   `json:"wagered" bson:"wagered"`
+	Payouts             float64   `json:"payouts" bson:"payouts"`
+	GGR                 float64   `json:"ggr" bson:"-"`
+	AdminSkim           float64   `json:"admin_skim" bson:"admin_skim"`
+	ReferralCommissions float64   `json:"referral_commissions" bson:"referral_commissions"`
+	NetRevenue          float64   `json:"net_revenue" bson:"-"`
+	AverageBet          float64   `json:"average_bet" bson:"-"`
+	ActivePlayers       int64     `json:"active_players" bson:"active_players"`
+	NewDepositors       int64     `json:"new_depositors" bson:"new_depositors"`
+}
+
+// BallDistribution represents how bets on one ball performed
+type BallDistribution struct {
+	BallID   int     `json:"ball_id" bson:"_id"`
+	BallName string  `json:"ball_name" bson:"ball_name"`
+	Bets     int64   `json:"bets" bson:"bets"`
+	Wins     int64   `json:"wins" bson:"wins"`
+	Wagered  float64 `json:"wagered" bson:"wagered"`
+	Payouts  float64 `json:"payouts" bson:"payouts"`
+}
+
+// BasketDistribution represents how often bets landed in one basket
+type BasketDistribution struct {
+	BasketID   int     `json:"basket_id" bson:"_id"`
+	Multiplier float64 `json:"multiplier" bson:"-"`
+	Bets       int64   `json:"bets" bson:"bets"`
+	Wagered    float64 `json:"wagered" bson:"wagered"`
+	Payouts    float64 `json:"payouts" bson:"payouts"`
+}
+
+// OperatorAnalytics represents the operator metrics over a date range
+type OperatorAnalytics struct {
+	From        time.Time            `json:"from"`
+	To          time.Time            `json:"to"`
+	Granularity string               `json:"granularity"`
+	Totals      AnalyticsBucket      `json:"totals"`
+	Buckets     []AnalyticsBucket    `json:"buckets"`
+	Balls       []BallDistribution   `json:"balls"`
+	Baskets     []BasketDistribution `json:"baskets"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audited admin actions
+const (
+	AuditActionUserUpdate              = "user.update"
+	AuditActionUserDelete              = "user.delete"
+	AuditActionUserToggleStatus        = "user.toggle_status"
+	AuditActionUserAdjustBalance       = "user.adjust_balance"
+	AuditActionUserAssignRole          = "user.assign_role"
+	AuditActionRateLimitReset          = "rate_limit.reset"
+	AuditActionGameSimulate            = "game.simulate"
+	AuditActionRoleCreate              = "role.create"
+	AuditActionRoleUpdate              = "role.update"
+	AuditActionRoleDelete              = "role.delete"
+	AuditActionReferralProgramPublish  = "referral_program.publish"
+	AuditActionReferralProgramActivate = "referral_program.activate"
+	AuditActionEmailRetry              = "email.retry"
+	AuditActionDataExport              = "data.export"
+)
+
+// Audit target types
+const (
+	AuditTargetUser            = "user"
+	AuditTargetRateLimit       = "rate_limit"
+	AuditTargetGame            = "game"
+	AuditTargetRole            = "role"
+	AuditTargetReferralProgram = "referral_program"
+	AuditTargetEmail           = "email"
+	AuditTargetExport          = "export"
+)
+
+// AuditActor represents who performed a privileged action and from where
+type AuditActor struct {
+	UserID    string `json:"user_id" bson:"user_id"`
+	Email     string `json:"email" bson:"email"`
+	Role      string `json:"role" bson:"role"`
+	IP        string `json:"ip" bson:"ip"`
+	UserAgent string `json:"user_agent" bson:"user_agent"`
+	RequestID string `json:"request_id" bson:"request_id"`
+}
+
+// AuditLog represents one append-only record of a privileged action
+type AuditLog struct {
+	ID         primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	Actor      AuditActor             `json:"actor" bson:"actor"`
+	Action     string                 `json:"action" bson:"action"`
+	TargetType string                 `json:"target_type" bson:"target_type"`
+	TargetID   string                 `json:"target_id" bson:"target_id"`
+	Before     map[string]interface{} `json:"before,omitempty" bson:"before,omitempty"` // only fields that changed
+	After      map[string]interface{} `json:"after,omitempty" bson:"after,omitempty"`
+	CreatedAt  time.Time              `json:"created_at" bson:"created_at"`
+}
+
+// AuditLogFilter represents the search criteria for audit logs
+type AuditLogFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
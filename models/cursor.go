@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Game result and bet outcomes
+const (
+	OutcomeWon    = "won"
+	OutcomeLost   = "lost"
+	OutcomePushed = "pushed"
+)
+
+// IsValidOutcome checks if an outcome filter is known
+func IsValidOutcome(outcome string) bool {
+	return outcome == OutcomeWon || outcome == OutcomeLost || outcome == OutcomePushed
+}
+
+// Cursor marks the last item of a page in a newest-first listing. Items are ordered by
+// creation time, then ID, so items created at the same instant are neither skipped nor repeated.
+type Cursor struct {
+	CreatedAt time.Time          `json:"t"`
+	ID        primitive.ObjectID `json:"id"`
+}
+
+// Encode encodes the cursor into an opaque URL-safe token
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a token made by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID.IsZero() {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// CursorPage represents one page of a cursor-paginated listing.
+// NextCursor is empty on the last page.
+type CursorPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// NewCursorPage builds a page from up to limit+1 items fetched after the previous cursor;
+// the extra item only signals that another page exists
+func NewCursorPage[T any](items []T, limit int64, cursorOf func(*T) *Cursor) *CursorPage[T] {
+	page := &CursorPage[T]{Items: items}
+	if int64(len(items)) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = cursorOf(&page.Items[limit-1]).Encode()
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Outbox email statuses
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSending = "sending"
+	EmailStatusSent    = "sent"
+	EmailStatusDead    = "dead" // gave up after the maximum number of attempts
+)
+
+// OutboxEmail represents a rendered email queued for delivery by the email worker
+type OutboxEmail struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	To            string             `json:"to" bson:"to"`
+	Template      string             `json:"template" bson:"template"`
+	Locale        string             `json:"locale" bson:"locale"`
+	Subject       string             `json:"subject" bson:"subject"`
+	HTML          string             `json:"-" bson:"html,omitempty"` // bodies may contain codes, so they are never exposed
+	Text          string             `json:"-" bson:"text,omitempty"`
+	Status        string             `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	LastError     string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil   *time.Time         `json:"-" bson:"locked_until,omitempty"`
+	SentAt        *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// MailboxMessage represents an email captured by the in-memory development mail transport
+type MailboxMessage struct {
+	ID         int       `json:"id"`
+	From       string    `json:"from"`
+	To         []string  `json:"to"`
+	Subject    string    `json:"subject"`
+	Text       string    `json:"text"`
+	HTML       string    `json:"html"`
+	ReceivedAt time.Time `json:"received_at"`
+}
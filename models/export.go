@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Export formats
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson" // one JSON object per line
+)
+
+// BetFilter represents the listing and export criteria for bets
+type BetFilter struct {
+	UserID *primitive.ObjectID
+	GameID *primitive.ObjectID
+	BallID *int
+	Status string
+	From   *time.Time
+	To     *time.Time
+}
+
+// GameResultFilter represents the listing and export criteria for game results
+type GameResultFilter struct {
+	UserID  *primitive.ObjectID
+	GameID  *primitive.ObjectID
+	BallID  *int
+	Won     *bool
+	Outcome string // won, lost or pushed
+	From    *time.Time
+	To      *time.Time
+}
+
+// TransactionFilter represents the listing criteria for transactions
+type TransactionFilter struct {
+	UserID *primitive.ObjectID
+	Type   string
+	From   *time.Time
+	To     *time.Time
+}
+
+// CommissionFilter represents the admin export criteria for referral commissions
+type CommissionFilter struct {
+	ReferrerID     *primitive.ObjectID
+	ReferredUserID *primitive.ObjectID
+	Source         string
+	From           *time.Time
+	To             *time.Time
+}
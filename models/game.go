@@ -31,6 +31,9 @@ type Game struct {
 	TotalBets       float64            `json:"total_bets" bson:"total_bets"`
 	HouseWallet     float64            `json:"house_wallet" bson:"house_wallet"`
 	AdminProfit     float64            `json:"admin_profit" bson:"admin_profit"`
+	AdminSkim       float64            `json:"admin_skim" bson:"admin_skim"` // admin profit taken when the round settled
+	TrajectorySeed  string             `json:"trajectory_seed,omitempty" bson:"trajectory_seed,omitempty"`
+	Trajectories    []BallTrajectory   `json:"trajectories,omitempty" bson:"trajectories,omitempty"` // one per ball in play, set when drawn
 	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
 	CompletedAt     *time.Time         `json:"completed_at" bson:"completed_at,omitempty"`
@@ -269,3 +272,39 @@ func (req *PlaceBetRequest) Validate() error {
 
 	return nil
 }
+
+// RoundSummary represents a settled round in the public round history
+type RoundSummary struct {
+	ID                primitive.ObjectID `json:"id"`
+	RoundNumber       int                `json:"round_number"`
+	WinningBallID     int                `json:"winning_ball_id"`
+	WinningBallName   string             `json:"winning_ball_name"`
+	WinningBasketID   int                `json:"winning_basket_id"`
+	WinningMultiplier float64            `json:"winning_multiplier"`
+	Pool              float64            `json:"pool"` // total staked in the round
+	Bets              int64              `json:"bets"`
+	CreatedAt         time.Time          `json:"created_at"`
+	CompletedAt       *time.Time         `json:"completed_at"`
+}
+
+// RoundBallStats represents the anonymized bets on one ball of a round and where it landed
+type RoundBallStats struct {
+	GameID       primitive.ObjectID `json:"-" bson:"game_id"`
+	BallID       int                `json:"ball_id" bson:"ball_id"`
+	BallName     string             `json:"ball_name" bson:"ball_name"`
+	BasketLanded int                `json:"basket_landed" bson:"basket_landed"`
+	Multiplier   float64            `json:"multiplier" bson:"-"` // basket multiplier before wallet limits
+	Bets         int64              `json:"bets" bson:"bets"`
+	Players      int64              `json:"players" bson:"players"`
+	Wagered      float64            `json:"wagered" bson:"wagered"`
+	Payouts      float64            `json:"payouts" bson:"payouts"`
+}
+
+// RoundDetail represents the full outcome of a settled round as seen by one player
+type RoundDetail struct {
+	RoundSummary
+	Balls          []RoundBallStats `json:"balls"`
+	TrajectorySeed string           `json:"trajectory_seed,omitempty"`
+	Trajectories   []BallTrajectory `json:"trajectories"`
+	MyBets         []GameResult     `json:"my_bets"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification categories
+const (
+	NotificationCategoryGame     = "game"     // round results
+	NotificationCategoryReferral = "referral" // referral commissions
+	NotificationCategoryPayment  = "payment"  // deposits and withdrawals
+)
+
+// NotificationCategories lists every notification category
+var NotificationCategories = []string{
+	NotificationCategoryGame,
+	NotificationCategoryReferral,
+	NotificationCategoryPayment,
+}
+
+// DefaultEmailNotifications decides which categories are emailed when a user has not chosen
+var DefaultEmailNotifications = map[string]bool{
+	NotificationCategoryGame:     false,
+	NotificationCategoryReferral: true,
+	NotificationCategoryPayment:  true,
+}
+
+// IsValidNotificationCategory checks if a notification category is known
+func IsValidNotificationCategory(category string) bool {
+	for _, c := range NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification represents an in-app notification for a user
+type Notification struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID     `json:"user_id" bson:"user_id"`
+	Category  string                 `json:"category" bson:"category"`
+	Type      string                 `json:"type" bson:"type"` // e.g. round_won, commission_earned
+	Title     string                 `json:"title" bson:"title"`
+	Message   string                 `json:"message" bson:"message"`
+	Data      map[string]interface{} `json:"data,omitempty" bson:"data,omitempty"`
+	Read      bool                   `json:"read" bson:"read"`
+	ReadAt    *time.Time             `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	CreatedAt time.Time              `json:"created_at" bson:"created_at"`
+}
+
+// MarkNotificationsReadRequest represents the bulk mark-read payload; no IDs marks everything read
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids,omitempty" validate:"omitempty,max=100,dive,len=24,hexadecimal"`
+}
+
+// NotificationPreferencesRequest represents the per-category email preferences payload
+type NotificationPreferencesRequest struct {
+	Email map[string]bool `json:"email" validate:"required,min=1"`
+}
@@ -14,6 +14,7 @@ type OTP struct {
 	Type      string             `json:"type" bson:"type"` // registration, password_reset, etc.
 	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
 	IsUsed    bool               `json:"is_used" bson:"is_used"`
+	Attempts  int                `json:"attempts" bson:"attempts"` // verification attempts made with this code
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 }
 
@@ -23,6 +24,18 @@ type VerifyOTPRequest struct {
 	OTP   string `json:"otp" validate:"required,len=6"`
 }
 
+// ForgotPasswordRequest represents the forgot password request payload
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents the password reset request payload
+type ResetPasswordRequest struct {
+	Email       string `json:"email" validate:"required,email"`
+	OTP         string `json:"otp" validate:"required,len=6"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
 // IsExpired checks if the OTP is expired
 func (o *OTP) IsExpired() bool {
 	return time.Now().After(o.ExpiresAt)
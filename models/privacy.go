@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UserDataExport bundles everything stored about a user for a data access request
+type UserDataExport struct {
+	GeneratedAt         time.Time            `json:"generated_at"`
+	Profile             *User                `json:"profile"`
+	Bets                []Bet                `json:"bets"`
+	GameResults         []GameResult         `json:"game_results"`
+	Transactions        []Transaction        `json:"transactions"`
+	ReferralCommissions []ReferralCommission `json:"referral_commissions"`
+	ReferredUsers       []ReferredUser       `json:"referred_users"`
+	Notifications       []Notification       `json:"notifications"`
+}
+
+// DeleteAccountRequest represents the request payload to erase the current account
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
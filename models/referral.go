@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReferredUser represents a referred user as shown to their referrer (personal fields masked)
+type ReferredUser struct {
+	ID              primitive.ObjectID `json:"id"`
+	Username        string             `json:"username"`
+	Email           string             `json:"email"`
+	IsActive        bool               `json:"is_active"`
+	IsEmailVerified bool               `json:"is_email_verified"`
+	TotalCommission float64            `json:"total_commission"`
+	JoinedAt        time.Time          `json:"joined_at"`
+}
+
+// ReferralEarningsPoint represents referral earnings within a single time bucket
+type ReferralEarningsPoint struct {
+	Period string  `json:"period" bson:"period"`
+	Amount float64 `json:"amount" bson:"amount"`
+	Count  int64   `json:"count" bson:"count"`
+}
+
+// ReferralDashboard represents the referral overview returned to a referrer
+type ReferralDashboard struct {
+	ReferralCode     string                  `json:"referral_code"`
+	ReferralCount    int64                   `json:"referral_count"`
+	ActiveReferrals  int64                   `json:"active_referrals"`
+	TotalEarnings    float64                 `json:"total_earnings"`
+	ReferredUsers    []ReferredUser          `json:"referred_users"`
+	Commissions      []ReferralCommission    `json:"commissions"`
+	CommissionCount  int64                   `json:"commission_count"`
+	EarningsOverTime []ReferralEarningsPoint `json:"earnings_over_time"`
+}
+
+const (
+	// ReferralModeDeposit pays commissions on referred users' deposits
+	ReferralModeDeposit = "deposit"
+	// ReferralModeRevenueShare pays commissions on referred users' net losses at game settlement
+	ReferralModeRevenueShare = "revenue_share"
+
+	// MaxReferralLevels caps how far up the referral chain commissions are paid
+	MaxReferralLevels = 5
+	// MaxReferralTotalRate caps the combined commission rate across all levels
+	MaxReferralTotalRate = 0.5
+)
+
+// ReferralTier represents the commission rate paid to the referrer at a given level
+// (level 1 is the direct referrer, level 2 the referrer's referrer, and so on)
+type ReferralTier struct {
+	Level int     `json:"level" bson:"level" validate:"required,min=1"`
+	Rate  float64 `json:"rate" bson:"rate" validate:"required,gt=0"`
+}
+
+// ReferralProgram represents one immutable version of the referral commission rules
+type ReferralProgram struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Version   int                 `json:"version" bson:"version"`
+	Mode      string              `json:"mode" bson:"mode"`
+	Tiers     []ReferralTier      `json:"tiers" bson:"tiers"`
+	Increment float64             `json:"increment" bson:"increment"` // commissionable amount is rounded down to this increment (0 = none)
+	Notes     string              `json:"notes" bson:"notes"`
+	IsActive  bool                `json:"is_active" bson:"-"` // filled in from the active version kept by the repository
+	CreatedBy *primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// DefaultReferralProgram returns the rules used until an admin publishes a program:
+// 0.5% to the direct referrer for every full $100 deposited
+func DefaultReferralProgram() *ReferralProgram {
+	return &ReferralProgram{
+		Version:   0,
+		Mode:      ReferralModeDeposit,
+		Tiers:     []ReferralTier{{Level: 1, Rate: 0.005}},
+		Increment: 100,
+		IsActive:  true,
+	}
+}
+
+// CalculateCommission calculates the commission for a base amount at the given rate
+func (p *ReferralProgram) CalculateCommission(baseAmount, rate float64) float64 {
+	if p.Increment > 0 {
+		// Only pay commission for complete increments
+		baseAmount = math.Floor(baseAmount/p.Increment) * p.Increment
+	}
+	if baseAmount <= 0 {
+		return 0
+	}
+
+	return math.Round(baseAmount*rate*100) / 100 // Round to 2 decimal places
+}
+
+// ReferralProgramRequest represents a request to publish a new referral program version
+type ReferralProgramRequest struct {
+	Mode      string         `json:"mode" validate:"required,oneof=deposit revenue_share"`
+	Tiers     []ReferralTier `json:"tiers" validate:"required,min=1,dive"`
+	Increment float64        `json:"increment" validate:"min=0"`
+	Notes     string         `json:"notes,omitempty" validate:"omitempty,max=500"`
+}
+
+// Validate validates the tier layout of a referral program request
+func (req *ReferralProgramRequest) Validate() error {
+	if len(req.Tiers) > MaxReferralLevels {
+		return fmt.Errorf("maximum %d referral levels are allowed", MaxReferralLevels)
+	}
+
+	seen := make(map[int]bool)
+	totalRate := 0.0
+	for _, tier := range req.Tiers {
+		if tier.Level < 1 || tier.Level > len(req.Tiers) {
+			return fmt.Errorf("invalid referral level: %d", tier.Level)
+		}
+		if seen[tier.Level] {
+			return fmt.Errorf("duplicate referral level: %d", tier.Level)
+		}
+		seen[tier.Level] = true
+		totalRate += tier.Rate
+	}
+
+	if totalRate > MaxReferralTotalRate {
+		return fmt.Errorf("combined commission rate must not exceed %.0f%%", MaxReferralTotalRate*100)
+	}
+
+	return nil
+}
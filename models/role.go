@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Permissions granted to roles
+const (
+	PermissionUsersRead          = "users.read"
+	PermissionUsersWrite         = "users.write"
+	PermissionWalletManage       = "wallet.manage"
+	PermissionWithdrawalsApprove = "withdrawals.approve"
+	PermissionConfigRead         = "config.read"
+	PermissionConfigEdit         = "config.edit"
+	PermissionGamesRead          = "games.read"
+	PermissionGamesManage        = "games.manage"
+	PermissionRolesManage        = "roles.manage"
+	PermissionAuditRead          = "audit.read"
+	PermissionEmailsManage       = "emails.manage"
+)
+
+// Built-in role names
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+// AllPermissions lists every permission that can be granted to a role
+var AllPermissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionWalletManage,
+	PermissionWithdrawalsApprove,
+	PermissionConfigRead,
+	PermissionConfigEdit,
+	PermissionGamesRead,
+	PermissionGamesManage,
+	PermissionRolesManage,
+	PermissionAuditRead,
+	PermissionEmailsManage,
+}
+
+// IsValidPermission checks if a permission is known
+func IsValidPermission(permission string) bool {
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Role represents a named set of permissions assigned to users
+type Role struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	Permissions []string           `json:"permissions" bson:"permissions"`
+	IsSystem    bool               `json:"is_system" bson:"is_system"` // built-in roles cannot be deleted
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// HasPermission checks if the role grants a permission. Superadmin implicitly holds every permission.
+func (r *Role) HasPermission(permission string) bool {
+	if r.Name == RoleSuperAdmin {
+		return true
+	}
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaff checks if the role grants any permission, i.e. gives access to the admin API
+func (r *Role) IsStaff() bool {
+	return r.Name == RoleSuperAdmin || len(r.Permissions) > 0
+}
+
+// DefaultRoles returns the built-in roles seeded on startup
+func DefaultRoles() []Role {
+	adminPermissions := make([]string, 0, len(AllPermissions))
+	for _, p := range AllPermissions {
+		if p != PermissionRolesManage {
+			adminPermissions = append(adminPermissions, p)
+		}
+	}
+
+	return []Role{
+		{Name: RoleUser, Description: "Regular player", Permissions: []string{}, IsSystem: true},
+		{Name: RoleAdmin, Description: "Operator with full access except role management", Permissions: adminPermissions, IsSystem: true},
+		{Name: RoleSuperAdmin, Description: "Full access, including role management", Permissions: AllPermissions, IsSystem: true},
+	}
+}
+
+// CreateRoleRequest represents a request to create a role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=3,max=30,alphanum"`
+	Description string   `json:"description,omitempty" validate:"omitempty,max=200"`
+	Permissions []string `json:"permissions" validate:"required,dive,required"`
+}
+
+// UpdateRoleRequest represents a request to update a role
+type UpdateRoleRequest struct {
+	Description *string  `json:"description,omitempty" validate:"omitempty,max=200"`
+	Permissions []string `json:"permissions,omitempty" validate:"omitempty,dive,required"`
+}
+
+// AssignRoleRequest represents a request to change a user's role
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Session represents a signed-in device. Its ID is also the refresh token family ID.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// ClientInfo represents the client a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Device    string
+}
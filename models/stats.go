@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DailyStats represents the global totals of the rounds settled on one UTC day
+type DailyStats struct {
+	ID        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	Day       time.Time          `json:"day" bson:"day"`
+	Rounds    int64              `json:"rounds" bson:"rounds"`
+	Bets      int64              `json:"bets" bson:"bets"`
+	Wins      int64              `json:"wins" bson:"wins"`
+	Pushes    int64              `json:"pushes" bson:"pushes"`
+	Players   int64              `json:"players" bson:"players"` // distinct players that day
+	Wagered   float64            `json:"wagered" bson:"wagered"`
+	Payouts   float64            `json:"payouts" bson:"payouts"`
+	AdminSkim float64            `json:"admin_skim" bson:"admin_skim"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// DailyUserStats represents one player's totals for the bets settled on one UTC day
+type DailyUserStats struct {
+	ID         primitive.ObjectID        `json:"-" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID        `json:"user_id" bson:"user_id"`
+	Day        time.Time                 `json:"day" bson:"day"`
+	Rounds     int64                     `json:"rounds" bson:"rounds"`
+	Bets       int64                     `json:"bets" bson:"bets"`
+	Wins       int64                     `json:"wins" bson:"wins"`
+	Pushes     int64                     `json:"pushes" bson:"pushes"`
+	Wagered    float64                   `json:"wagered" bson:"wagered"`
+	Payouts    float64                   `json:"payouts" bson:"payouts"`
+	Profit     float64                   `json:"profit" bson:"profit"` // net of every settled bet
+	Balls      map[string]DailyBallStats `json:"balls" bson:"balls"`   // keyed by ball ID
+	BiggestWin *GameResult               `json:"biggest_win,omitempty" bson:"biggest_win,omitempty"`
+	Streaks    StatsStreaks              `json:"streaks" bson:"streaks"`
+	UpdatedAt  time.Time                 `json:"updated_at" bson:"updated_at"`
+}
+
+// DailyBallStats represents one player's totals for the bets on one ball on one UTC day
+type DailyBallStats struct {
+	Bets    int64   `json:"bets" bson:"bets"`
+	Wins    int64   `json:"wins" bson:"wins"`
+	Pushes  int64   `json:"pushes" bson:"pushes"`
+	Wagered float64 `json:"wagered" bson:"wagered"`
+	Payouts float64 `json:"payouts" bson:"payouts"`
+	Profit  float64 `json:"profit" bson:"profit"`
+}
+
+// StatsStreaks summarizes the win and loss runs of a sequence of settled bets, so consecutive
+// sequences can be combined without replaying them. Lead and Trail are the lengths of the
+// opening and closing runs, positive for wins and negative for losses; a push ends a run.
+type StatsStreaks struct {
+	Bets        int64 `json:"bets" bson:"bets"`
+	Lead        int64 `json:"lead" bson:"lead"`
+	Trail       int64 `json:"trail" bson:"trail"`
+	LongestWin  int64 `json:"longest_win" bson:"longest_win"`
+	LongestLoss int64 `json:"longest_loss" bson:"longest_loss"`
+}
+
+// ResultStreaks gets the streaks of a single settled bet
+func ResultStreaks(result *GameResult) StatsStreaks {
+	switch {
+	case result.Won:
+		return StatsStreaks{Bets: 1, Lead: 1, Trail: 1, LongestWin: 1}
+	case result.Pushed:
+		return StatsStreaks{Bets: 1}
+	default:
+		return StatsStreaks{Bets: 1, Lead: -1, Trail: -1, LongestLoss: 1}
+	}
+}
+
+// Then combines the streaks of a sequence with those of the sequence settled right after it
+func (s StatsStreaks) Then(next StatsStreaks) StatsStreaks {
+	if s.Bets == 0 {
+		return next
+	}
+	if next.Bets == 0 {
+		return s
+	}
+
+	// The closing run continues into the next sequence's opening run if both are wins or both losses
+	var joined int64
+	if s.Trail > 0 && next.Lead > 0 || s.Trail < 0 && next.Lead < 0 {
+		joined = s.Trail + next.Lead
+	}
+
+	combined := StatsStreaks{
+		Bets:        s.Bets + next.Bets,
+		Lead:        s.Lead,
+		Trail:       next.Trail,
+		LongestWin:  max(s.LongestWin, next.LongestWin, joined),
+		LongestLoss: max(s.LongestLoss, next.LongestLoss, -joined),
+	}
+	if joined != 0 && s.isSingleRun() {
+		combined.Lead = joined
+	}
+	if joined != 0 && next.isSingleRun() {
+		combined.Trail = joined
+	}
+	return combined
+}
+
+// isSingleRun checks if the whole sequence is one run of wins or of losses
+func (s StatsStreaks) isSingleRun() bool {
+	return s.Lead == s.Bets || s.Lead == -s.Bets
+}
+
+// PlayerStats represents a player's betting statistics over an optional time range.
+// Rates and ROI are percentages; losses are settled bets that neither won nor pushed.
+type PlayerStats struct {
+	From              *time.Time        `json:"from,omitempty"`
+	To                *time.Time        `json:"to,omitempty"`
+	Rounds            int64             `json:"rounds"`
+	Bets              int64             `json:"bets"`
+	Wins              int64             `json:"wins"`
+	Pushes            int64             `json:"pushes"`
+	Losses            int64             `json:"losses"`
+	Wagered           float64           `json:"wagered"`
+	Payouts           float64           `json:"payouts"`
+	NetProfit         float64           `json:"net_profit"`
+	ROI               float64           `json:"roi"`
+	WinRate           float64           `json:"win_rate"`
+	PushRate          float64           `json:"push_rate"`
+	AverageBet        float64           `json:"average_bet"`
+	LongestWinStreak  int64             `json:"longest_win_streak"`
+	LongestLossStreak int64             `json:"longest_loss_streak"`
+	BiggestWin        *GameResult       `json:"biggest_win"`
+	Balls             []PlayerBallStats `json:"balls"`
+}
+
+// PlayerBallStats represents a player's statistics for bets on one ball
+type PlayerBallStats struct {
+	BallID    int     `json:"ball_id"`
+	BallName  string  `json:"ball_name"`
+	Bets      int64   `json:"bets"`
+	Wins      int64   `json:"wins"`
+	Pushes    int64   `json:"pushes"`
+	Losses    int64   `json:"losses"`
+	Wagered   float64 `json:"wagered"`
+	Payouts   float64 `json:"payouts"`
+	NetProfit float64 `json:"net_profit"`
+	WinRate   float64 `json:"win_rate"`
+}
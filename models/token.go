@@ -0,0 +1,19 @@
+package models
+
+// TokenPair represents an access token together with the refresh token that renews it
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token lifetime in seconds
+}
+
+// RefreshTokenRecord represents the server-side state of a refresh token
+type RefreshTokenRecord struct {
+	UserID   string `json:"user_id"`
+	FamilyID string `json:"family_id"` // ID of the session the token belongs to
+}
+
+// RefreshTokenRequest represents the token refresh request payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Trajectory board layout. With one peg row fewer than baskets, a ball that bounces right
+// k times lands in basket k. Positions are normalized: x from 0 (left) to 1 (right) and
+// y from 0 (drop point) to 1 (baskets).
+const (
+	TrajectoryPegRows     = 7
+	TrajectoryDropMs      = 400 // from release to the first peg
+	TrajectoryRowMs       = 180 // average time between two peg rows
+	TrajectoryRowJitterMs = 30
+	TrajectoryFallMs      = 300 // from the last peg into the basket
+)
+
+// Bounce directions
+const (
+	BounceLeft  = "L"
+	BounceRight = "R"
+)
+
+// TrajectoryKeyframe represents where the ball is at a point in time
+type TrajectoryKeyframe struct {
+	T int     `json:"t" bson:"t"` // milliseconds since release
+	X float64 `json:"x" bson:"x"`
+	Y float64 `json:"y" bson:"y"`
+}
+
+// BallTrajectory represents the drop of one ball, from release to its basket
+type BallTrajectory struct {
+	BallID     int                  `json:"ball_id" bson:"ball_id"`
+	Basket     int                  `json:"basket" bson:"basket"`
+	Bounces    []string             `json:"bounces" bson:"bounces"` // one direction per peg row
+	DurationMs int                  `json:"duration_ms" bson:"duration_ms"`
+	Keyframes  []TrajectoryKeyframe `json:"keyframes" bson:"keyframes"`
+}
+
+// NewTrajectorySeed generates a random seed for a round's trajectories
+func NewTrajectorySeed() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := crand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// BuildTrajectory derives the drop of a ball into a basket from a round seed. The same seed,
+// ball and basket always give the same trajectory, so it can be verified and replayed.
+func BuildTrajectory(seed string, ballID, basket int) BallTrajectory {
+	if basket < 0 {
+		basket = 0
+	}
+	if basket > TrajectoryPegRows {
+		basket = TrajectoryPegRows
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", seed, ballID)))
+	rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+
+	// Exactly basket right bounces, in a seeded order
+	bounces := make([]string, TrajectoryPegRows)
+	for i := range bounces {
+		if i < basket {
+			bounces[i] = BounceRight
+		} else {
+			bounces[i] = BounceLeft
+		}
+	}
+	rng.Shuffle(len(bounces), func(i, j int) { bounces[i], bounces[j] = bounces[j], bounces[i] })
+
+	spacing := 1.0 / float64(TrajectoryPegRows+1)
+	rowHeight := 1.0 / float64(TrajectoryPegRows+1)
+
+	keyframes := []TrajectoryKeyframe{{T: 0, X: 0.5, Y: 0}}
+	t := TrajectoryDropMs
+	rights := 0
+	for row, bounce := range bounces {
+		// Peg r holds r+1 positions centred on the board; the ball touches it slightly off-centre
+		x := 0.5 + (float64(rights)-float64(row)/2)*spacing
+		x += (rng.Float64() - 0.5) * spacing * 0.3
+		keyframes = append(keyframes, TrajectoryKeyframe{
+			T: t,
+			X: roundTrajectory(x),
+			Y: roundTrajectory(float64(row+1) * rowHeight),
+		})
+
+		if bounce == BounceRight {
+			rights++
+		}
+		if row < TrajectoryPegRows-1 {
+			t += TrajectoryRowMs + rng.Intn(2*TrajectoryRowJitterMs+1) - TrajectoryRowJitterMs
+		}
+	}
+	t += TrajectoryFallMs
+
+	// Basket k is centred under the gap the ball leaves the last row through
+	keyframes = append(keyframes, TrajectoryKeyframe{
+		T: t,
+		X: roundTrajectory((float64(basket) + 0.5) * spacing),
+		Y: 1,
+	})
+
+	return BallTrajectory{
+		BallID:     ballID,
+		Basket:     basket,
+		Bounces:    bounces,
+		DurationMs: t,
+		Keyframes:  keyframes,
+	}
+}
+
+// roundTrajectory rounds a coordinate to keep stored trajectories compact
+func roundTrajectory(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
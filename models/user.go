@@ -21,12 +21,20 @@ type User struct {
 	Location        Location           `json:"location" bson:"location"`
 	Balance         float64            `json:"balance" bson:"balance" validate:"min=0"`
 	Withdraw        float64            `json:"withdraw" bson:"withdraw" validate:"min=0"`
-	Role            string             `json:"role" bson:"role" validate:"required,oneof=user admin"`
+	Role            string             `json:"role" bson:"role" validate:"required"`
 	IsActive        bool               `json:"is_active" bson:"is_active"`
 	IsEmailVerified bool               `json:"is_email_verified" bson:"is_email_verified"`
 	ReferralCode    string             `json:"referral_code" bson:"referral_code" validate:"required"`
 	ReferredBy      *primitive.ObjectID `json:"referred_by" bson:"referred_by,omitempty"`
 	ReferralEarnings float64           `json:"referral_earnings" bson:"referral_earnings" validate:"min=0"`
+	TwoFactorEnabled       bool     `json:"two_factor_enabled" bson:"two_factor_enabled"`
+	TwoFactorSecret        string   `json:"-" bson:"two_factor_secret,omitempty"`
+	TwoFactorPendingSecret string   `json:"-" bson:"two_factor_pending_secret,omitempty"`
+	TwoFactorRecoveryCodes []string `json:"-" bson:"two_factor_recovery_codes,omitempty"` // SHA-256 hashes
+	PendingEmail           string   `json:"-" bson:"pending_email,omitempty"`             // awaiting OTP confirmation
+	Language               string   `json:"language" bson:"language,omitempty"`         // email locale, e.g. "en"
+	EmailNotifications     map[string]bool `json:"-" bson:"email_notifications,omitempty"` // per-category overrides of DefaultEmailNotifications
+	DeletedAt              *time.Time      `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"` // set when personal data was erased
 	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
 }
@@ -55,6 +63,14 @@ func (u *User) GetFullName() string {
 	return u.FirstName + " " + u.LastName
 }
 
+// WantsEmailNotification checks if the user receives notifications of a category by email
+func (u *User) WantsEmailNotification(category string) bool {
+	if enabled, ok := u.EmailNotifications[category]; ok {
+		return enabled
+	}
+	return DefaultEmailNotifications[category]
+}
+
 // IsValidLocation checks if the user's location is complete
 func (u *User) IsValidLocation() bool {
 	return u.Location.Country != "" && u.Location.State != "" && u.Location.City != ""
@@ -87,6 +103,8 @@ type UserResponse struct {
 	ReferralCode    string             `json:"referral_code"`
 	ReferredBy      *primitive.ObjectID `json:"referred_by"`
 	ReferralEarnings float64           `json:"referral_earnings"`
+	TwoFactorEnabled bool              `json:"two_factor_enabled"`
+	Language        string             `json:"language"`
 	CreatedAt       time.Time          `json:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at"`
 }
@@ -109,10 +127,12 @@ type RegisterRequest struct {
 	PhoneNumber  string    `json:"phone_number,omitempty" validate:"omitempty,min=10,max=15"`
 	Location     *Location `json:"location,omitempty"`
 	ReferralCode string    `json:"referral_code,omitempty" validate:"omitempty,min=6,max=20"`
+	Language     string    `json:"language,omitempty" validate:"omitempty,max=35"`
 }
 
-// UpdateUserRequest represents the user update request payload
-type UpdateUserRequest struct {
+// UpdateProfileRequest represents the self-service profile update payload.
+// Financial fields are deliberately absent; they only change through payments and adjustments.
+type UpdateProfileRequest struct {
 	Username    *string   `json:"username,omitempty" validate:"omitempty,min=3,max=20"`
 	FirstName   *string   `json:"first_name,omitempty" validate:"omitempty,min=2,max=50"`
 	LastName    *string   `json:"last_name,omitempty" validate:"omitempty,min=2,max=50"`
@@ -120,14 +140,63 @@ type UpdateUserRequest struct {
 	DOB         *string   `json:"dob,omitempty" validate:"omitempty"`
 	PhoneNumber *string   `json:"phone_number,omitempty" validate:"omitempty,min=10,max=15"`
 	Location    *Location `json:"location,omitempty"`
-	Balance     *float64  `json:"balance,omitempty" validate:"omitempty,min=0"`
-	Withdraw    *float64  `json:"withdraw,omitempty" validate:"omitempty,min=0"`
+	Language    *string   `json:"language,omitempty" validate:"omitempty,oneof=en fr"`
+}
+
+// AdminUpdateUserRequest represents the admin user update payload.
+// Balance changes go through AdminBalanceAdjustmentRequest instead.
+type AdminUpdateUserRequest struct {
+	Username    *string   `json:"username,omitempty" validate:"omitempty,min=3,max=20"`
+	FirstName   *string   `json:"first_name,omitempty" validate:"omitempty,min=2,max=50"`
+	LastName    *string   `json:"last_name,omitempty" validate:"omitempty,min=2,max=50"`
+	PhoneNumber *string   `json:"phone_number,omitempty" validate:"omitempty,min=10,max=15"`
+	Location    *Location `json:"location,omitempty"`
+}
+
+// User list sort columns
+var UserSortFields = []string{"created_at", "updated_at", "username", "email", "balance", "referral_earnings"}
+
+// UserFilter represents the admin search criteria for users
+type UserFilter struct {
+	Search          string // prefix of email, username or referral code
+	Role            string
+	IsActive        *bool
+	IsEmailVerified *bool
+	IsDeleted       *bool
+	MinBalance      *float64
+	MaxBalance      *float64
+	Country         string
+	ReferredBy      *primitive.ObjectID
+	CreatedFrom     *time.Time
+	CreatedTo       *time.Time
+	SortBy          string
+	SortDesc        bool
+}
+
+// ChangePasswordRequest represents the request payload to change the current password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+// ChangeEmailRequest represents the request payload to start an email change
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ConfirmEmailChangeRequest represents the request payload to confirm a new email address
+type ConfirmEmailChangeRequest struct {
+	OTP string `json:"otp" validate:"required,len=6"`
 }
 
 // AuthResponse represents the authentication response
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int64        `json:"expires_in"`
+	User         UserResponse `json:"user"`
+	RecoveryCodes []string    `json:"recovery_codes,omitempty"` // only set when 2FA was enrolled during login
 }
 
 // APIResponse represents a standard API response
@@ -145,6 +214,13 @@ type BalanceUpdateRequest struct {
 	Reason string  `json:"reason,omitempty"`
 }
 
+// AdminBalanceAdjustmentRequest represents an admin request to adjust a user's balance
+type AdminBalanceAdjustmentRequest struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+	Type   string  `json:"type" validate:"required,oneof=add subtract"`
+	Reason string  `json:"reason" validate:"required,min=3,max=500"`
+}
+
 // WithdrawRequest represents a withdrawal request
 type WithdrawRequest struct {
 	Amount      float64 `json:"amount" validate:"required,gt=0"`
@@ -156,10 +232,12 @@ type WithdrawRequest struct {
 type Transaction struct {
 	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
-	Type        string             `json:"type" bson:"type" validate:"required,oneof=deposit withdrawal transfer"`
-	Amount      float64            `json:"amount" bson:"amount" validate:"required,gt=0"`
+	Type        string             `json:"type" bson:"type" validate:"required,oneof=deposit withdrawal transfer adjustment"`
+	Amount      float64            `json:"amount" bson:"amount" validate:"required"` // signed change to the balance: negative for withdrawals and debits
 	Balance     float64            `json:"balance" bson:"balance"`
 	Description string             `json:"description" bson:"description"`
+	AdminID     *primitive.ObjectID `json:"admin_id,omitempty" bson:"admin_id,omitempty"` // set for admin adjustments
+	Reason      string             `json:"reason,omitempty" bson:"reason,omitempty"`
 	Status      string             `json:"status" bson:"status" validate:"required,oneof=pending completed failed"`
 	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
@@ -170,6 +248,10 @@ type ReferralCommission struct {
 	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	ReferrerID      primitive.ObjectID `json:"referrer_id" bson:"referrer_id"`
 	ReferredUserID  primitive.ObjectID `json:"referred_user_id" bson:"referred_user_id"`
+	GameID          *primitive.ObjectID `json:"game_id,omitempty" bson:"game_id,omitempty"`
+	Level           int                `json:"level" bson:"level"`
+	Source          string             `json:"source" bson:"source"` // deposit, revenue_share
+	ProgramVersion  int                `json:"program_version" bson:"program_version"`
 	OriginalAmount  float64            `json:"original_amount" bson:"original_amount"`
 	CommissionRate  float64            `json:"commission_rate" bson:"commission_rate"`
 	CommissionAmount float64           `json:"commission_amount" bson:"commission_amount"`
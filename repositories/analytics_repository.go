@@ -0,0 +1,210 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AnalyticsRepository runs the operator reporting aggregations over games, results, commissions and transactions
+type AnalyticsRepository struct {
+	db *mongo.Database
+}
+
+// NewAnalyticsRepository creates a new analytics repository
+func NewAnalyticsRepository(db *mongo.Database) *AnalyticsRepository {
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Settled rounds are bucketed by completion time
+	db.Collection("games").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "completed_at", Value: 1}},
+	})
+
+	// First deposits are found per user
+	db.Collection("transactions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "type", Value: 1}, {Key: "status", Value: 1}, {Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+
+	return &AnalyticsRepository{
+		db: db,
+	}
+}
+
+// ResultBuckets sums bets, wagers, payouts and active players per bucket from game results
+func (r *AnalyticsRepository) ResultBuckets(ctx context.Context, from, to time.Time, unit string) ([]models.AnalyticsBucket, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"created_at": periodRange(from, to)}},
+		// Group per player first so active players are counted without collecting every ID in one document
+		{"$group": bson.M{
+			"_id":     bson.M{"bucket": bucketStart("$created_at", unit), "user_id": "$user_id"},
+			"bets":    bson.M{"$sum": 1},
+			"wagered": bson.M{"$sum": "$bet_amount"},
+			"payouts": bson.M{"$sum": "$win_amount"},
+		}},
+		{"$group": bson.M{
+			"_id":            "$_id.bucket",
+			"bets":           bson.M{"$sum": "$bets"},
+			"wagered":        bson.M{"$sum": "$wagered"},
+			"payouts":        bson.M{"$sum": "$payouts"},
+			"active_players": bson.M{"$sum": 1},
+		}},
+	}
+	return r.aggregateBuckets(ctx, "game_results", pipeline)
+}
+
+// SkimBuckets counts settled rounds and sums the admin skim per bucket
+func (r *AnalyticsRepository) SkimBuckets(ctx context.Context, from, to time.Time, unit string) ([]models.AnalyticsBucket, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"status": "completed", "completed_at": periodRange(from, to)}},
+		{"$group": bson.M{
+			"_id":        bucketStart("$completed_at", unit),
+			"rounds":     bson.M{"$sum": 1},
+			"admin_skim": bson.M{"$sum": "$admin_skim"},
+		}},
+	}
+	return r.aggregateBuckets(ctx, "games", pipeline)
+}
+
+// CommissionBuckets sums the referral commissions paid per bucket
+func (r *AnalyticsRepository) CommissionBuckets(ctx context.Context, from, to time.Time, unit string) ([]models.AnalyticsBucket, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"status": "completed", "created_at": periodRange(from, to)}},
+		{"$group": bson.M{
+			"_id":                  bucketStart("$created_at", unit),
+			"referral_commissions": bson.M{"$sum": "$commission_amount"},
+		}},
+	}
+	return r.aggregateBuckets(ctx, "referral_commissions", pipeline)
+}
+
+// NewDepositorBuckets counts the users whose first completed deposit falls in each bucket
+func (r *AnalyticsRepository) NewDepositorBuckets(ctx context.Context, from, to time.Time, unit string) ([]models.AnalyticsBucket, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"type": "deposit", "status": "completed", "created_at": bson.M{"$lt": to}}},
+		{"$group": bson.M{
+			"_id":           "$user_id",
+			"first_deposit": bson.M{"$min": "$created_at"},
+		}},
+		{"$match": bson.M{"first_deposit": periodRange(from, to)}},
+		{"$group": bson.M{
+			"_id":            bucketStart("$first_deposit", unit),
+			"new_depositors": bson.M{"$sum": 1},
+		}},
+	}
+	return r.aggregateBuckets(ctx, "transactions", pipeline)
+}
+
+// CountActivePlayers counts the distinct players with a settled bet in a period
+func (r *AnalyticsRepository) CountActivePlayers(ctx context.Context, from, to time.Time) (int64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"created_at": periodRange(from, to)}},
+		{"$group": bson.M{"_id": "$user_id"}},
+		{"$count": "players"},
+	}
+
+	cursor, err := r.db.Collection("game_results").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Players int64 `bson:"players"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Players, nil
+}
+
+// BallDistribution sums bets, wins, wagers and payouts per ball in a period
+func (r *AnalyticsRepository) BallDistribution(ctx context.Context, from, to time.Time) ([]models.BallDistribution, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"created_at": periodRange(from, to)}},
+		{"$group": bson.M{
+			"_id":       "$ball_id",
+			"ball_name": bson.M{"$last": "$ball_name"},
+			"bets":      bson.M{"$sum": 1},
+			"wins":      bson.M{"$sum": bson.M{"$cond": bson.A{"$won", 1, 0}}},
+			"wagered":   bson.M{"$sum": "$bet_amount"},
+			"payouts":   bson.M{"$sum": "$win_amount"},
+		}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	cursor, err := r.db.Collection("game_results").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var balls []models.BallDistribution
+	if err = cursor.All(ctx, &balls); err != nil {
+		return nil, err
+	}
+	return balls, nil
+}
+
+// BasketDistribution sums bets, wagers and payouts per landing basket in a period
+func (r *AnalyticsRepository) BasketDistribution(ctx context.Context, from, to time.Time) ([]models.BasketDistribution, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"created_at": periodRange(from, to)}},
+		{"$group": bson.M{
+			"_id":     "$basket_landed",
+			"bets":    bson.M{"$sum": 1},
+			"wagered": bson.M{"$sum": "$bet_amount"},
+			"payouts": bson.M{"$sum": "$win_amount"},
+		}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	cursor, err := r.db.Collection("game_results").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var baskets []models.BasketDistribution
+	if err = cursor.All(ctx, &baskets); err != nil {
+		return nil, err
+	}
+	return baskets, nil
+}
+
+// aggregateBuckets runs a pipeline whose output documents are keyed by bucket start
+func (r *AnalyticsRepository) aggregateBuckets(ctx context.Context, collection string, pipeline []bson.M) ([]models.AnalyticsBucket, error) {
+	cursor, err := r.db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var buckets []models.AnalyticsBucket
+	if err = cursor.All(ctx, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// bucketStart truncates a date field to the start of its UTC hour, day, Monday-based week or month
+func bucketStart(field, unit string) bson.M {
+	return bson.M{"$dateTrunc": bson.M{
+		"date":        field,
+		"unit":        unit,
+		"timezone":    "UTC",
+		"startOfWeek": "monday",
+	}}
+}
+
+// periodRange builds a half-open [from, to) range condition
+func periodRange(from, to time.Time) bson.M {
+	return bson.M{"$gte": from, "$lt": to}
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository handles audit log database operations. The log is append-only,
+// so there are deliberately no update or delete methods.
+type AuditRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *mongo.Database) *AuditRepository {
+	collection := db.Collection("audit_logs")
+
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	actorIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "actor.user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+
+	targetIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+
+	createdAtIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: -1}},
+	}
+
+	collection.Indexes().CreateMany(ctx, []mongo.IndexModel{actorIndex, targetIndex, createdAtIndex})
+
+	return &AuditRepository{collection: collection}
+}
+
+// Create appends an audit log entry
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	entry.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Search gets audit log entries matching a filter, newest first
+func (r *AuditRepository) Search(ctx context.Context, filter *models.AuditLogFilter, skip, limit int64) ([]models.AuditLog, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, buildAuditQuery(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditLog{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Count counts audit log entries matching a filter
+func (r *AuditRepository) Count(ctx context.Context, filter *models.AuditLogFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, buildAuditQuery(filter))
+}
+
+// buildAuditQuery converts an audit log filter into a Mongo query
+func buildAuditQuery(filter *models.AuditLogFilter) bson.M {
+	query := bson.M{}
+	if filter.ActorID != "" {
+		query["actor.user_id"] = filter.ActorID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetType != "" {
+		query["target_type"] = filter.TargetType
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+
+	createdAt := bson.M{}
+	if filter.From != nil {
+		createdAt["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		createdAt["$lte"] = *filter.To
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	return query
+}
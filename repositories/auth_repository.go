@@ -2,8 +2,13 @@ package repositories
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
 	"time"
 
+	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -31,19 +36,169 @@ func (r *AuthRepository) DeleteToken(ctx context.Context, token string) error {
 	return r.client.Del(ctx, "token:"+token).Err()
 }
 
+// refreshKey builds the Redis key for a refresh token. Tokens are stored hashed so
+// a Redis dump does not contain usable credentials.
+func refreshKey(prefix, refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return prefix + hex.EncodeToString(sum[:])
+}
+
 // SetRefreshToken stores a refresh token in Redis
-func (r *AuthRepository) SetRefreshToken(ctx context.Context, refreshToken, userID string, expiration time.Duration) error {
-	return r.client.Set(ctx, "refresh:"+refreshToken, userID, expiration).Err()
+func (r *AuthRepository) SetRefreshToken(ctx context.Context, refreshToken string, record *models.RefreshTokenRecord, expiration time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, refreshKey("refresh:", refreshToken), data, expiration).Err()
+}
+
+// GetRefreshToken gets a refresh token from Redis (nil if it does not exist)
+func (r *AuthRepository) GetRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshTokenRecord, error) {
+	data, err := r.client.Get(ctx, refreshKey("refresh:", refreshToken)).Bytes()
+	return decodeRefreshRecord(data, err)
+}
+
+// ConsumeRefreshToken atomically gets and removes a refresh token (nil if it does not exist)
+func (r *AuthRepository) ConsumeRefreshToken(ctx context.Context, refreshToken string) (*models.RefreshTokenRecord, error) {
+	data, err := r.client.GetDel(ctx, refreshKey("refresh:", refreshToken)).Bytes()
+	return decodeRefreshRecord(data, err)
 }
 
-// GetRefreshToken gets a refresh token from Redis
-func (r *AuthRepository) GetRefreshToken(ctx context.Context, refreshToken string) (string, error) {
-	return r.client.Get(ctx, "refresh:"+refreshToken).Result()
+// decodeRefreshRecord decodes a stored refresh token record
+func decodeRefreshRecord(data []byte, err error) (*models.RefreshTokenRecord, error) {
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record models.RefreshTokenRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
 }
 
 // DeleteRefreshToken removes a refresh token from Redis
 func (r *AuthRepository) DeleteRefreshToken(ctx context.Context, refreshToken string) error {
-	return r.client.Del(ctx, "refresh:"+refreshToken).Err()
+	return r.client.Del(ctx, refreshKey("refresh:", refreshToken)).Err()
+}
+
+// MarkRefreshTokenUsed remembers a rotated refresh token so later reuse can be detected
+func (r *AuthRepository) MarkRefreshTokenUsed(ctx context.Context, refreshToken, familyID string, expiration time.Duration) error {
+	return r.client.Set(ctx, refreshKey("refresh_used:", refreshToken), familyID, expiration).Err()
+}
+
+// GetUsedRefreshTokenFamily gets the family of an already rotated refresh token (empty if unknown)
+func (r *AuthRepository) GetUsedRefreshTokenFamily(ctx context.Context, refreshToken string) (string, error) {
+	familyID, err := r.client.Get(ctx, refreshKey("refresh_used:", refreshToken)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return familyID, err
+}
+
+// SaveSession stores a session (refresh token family) and indexes it under its user
+func (r *AuthRepository) SaveSession(ctx context.Context, session *models.Session, expiration time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, "session:"+session.ID, data, expiration)
+	pipe.SAdd(ctx, "user_sessions:"+session.UserID, session.ID)
+	pipe.Expire(ctx, "user_sessions:"+session.UserID, expiration)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// TouchSession updates a session's last-seen time and IP without changing its expiration
+func (r *AuthRepository) TouchSession(ctx context.Context, session *models.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return r.client.SetArgs(ctx, "session:"+session.ID, data, redis.SetArgs{KeepTTL: true, Mode: "XX"}).Err()
+}
+
+// GetSession gets a session by ID (nil if it does not exist or was revoked)
+func (r *AuthRepository) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	data, err := r.client.Get(ctx, "session:"+sessionID).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListSessionIDs gets the IDs of every session indexed under a user
+func (r *AuthRepository) ListSessionIDs(ctx context.Context, userID string) ([]string, error) {
+	return r.client.SMembers(ctx, "user_sessions:"+userID).Result()
+}
+
+// DeleteSession revokes a session and every token issued from it
+func (r *AuthRepository) DeleteSession(ctx context.Context, userID, sessionID string) error {
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, "session:"+sessionID)
+	pipe.SRem(ctx, "user_sessions:"+userID, sessionID)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SetTwoFactorChallenge stores a pending two-factor login challenge
+func (r *AuthRepository) SetTwoFactorChallenge(ctx context.Context, challengeToken string, record *models.TwoFactorChallengeRecord, expiration time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, refreshKey("2fa_challenge:", challengeToken), data, expiration).Err()
+}
+
+// UpdateTwoFactorChallenge updates a pending challenge without extending its expiration
+func (r *AuthRepository) UpdateTwoFactorChallenge(ctx context.Context, challengeToken string, record *models.TwoFactorChallengeRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.client.SetArgs(ctx, refreshKey("2fa_challenge:", challengeToken), data, redis.SetArgs{KeepTTL: true, Mode: "XX"}).Err()
+}
+
+// GetTwoFactorChallenge gets a pending two-factor login challenge (nil if expired or unknown)
+func (r *AuthRepository) GetTwoFactorChallenge(ctx context.Context, challengeToken string) (*models.TwoFactorChallengeRecord, error) {
+	data, err := r.client.Get(ctx, refreshKey("2fa_challenge:", challengeToken)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record models.TwoFactorChallengeRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// DeleteTwoFactorChallenge removes a two-factor login challenge
+func (r *AuthRepository) DeleteTwoFactorChallenge(ctx context.Context, challengeToken string) error {
+	return r.client.Del(ctx, refreshKey("2fa_challenge:", challengeToken)).Err()
+}
+
+// MarkTOTPStepUsed records a user's TOTP time step as used. It returns false if the
+// step was already used, i.e. the code is being replayed.
+func (r *AuthRepository) MarkTOTPStepUsed(ctx context.Context, userID string, step int64, expiration time.Duration) (bool, error) {
+	key := "totp_used:" + userID + ":" + strconv.FormatInt(step, 10)
+	return r.client.SetNX(ctx, key, "1", expiration).Result()
 }
 
 // SetBlacklistToken adds a token to the blacklist
@@ -63,6 +218,23 @@ func (r *AuthRepository) IsTokenBlacklisted(ctx context.Context, token string) (
 	return true, nil
 }
 
+// SetSessionsRevokedAt records that every token issued to a user up to revokedAt is revoked
+func (r *AuthRepository) SetSessionsRevokedAt(ctx context.Context, userID string, revokedAt time.Time, expiration time.Duration) error {
+	return r.client.Set(ctx, "sessions_revoked:"+userID, revokedAt.UnixMilli(), expiration).Err()
+}
+
+// GetSessionsRevokedAt gets the time up to which a user's tokens are revoked (zero if never)
+func (r *AuthRepository) GetSessionsRevokedAt(ctx context.Context, userID string) (time.Time, error) {
+	millis, err := r.client.Get(ctx, "sessions_revoked:"+userID).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
 // GetRedis returns the Redis client
 func (r *AuthRepository) GetRedis() *redis.Client {
 	return r.client
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EmailOutboxRepository handles the queue of emails waiting to be delivered
+type EmailOutboxRepository struct {
+	collection *mongo.Collection
+}
+
+// NewEmailOutboxRepository creates a new email outbox repository
+func NewEmailOutboxRepository(db *mongo.Database) *EmailOutboxRepository {
+	collection := db.Collection("email_outbox")
+
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The worker picks due messages by status and next attempt time
+	dueIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+	}
+
+	createdAtIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: -1}},
+	}
+
+	collection.Indexes().CreateMany(ctx, []mongo.IndexModel{dueIndex, createdAtIndex})
+
+	return &EmailOutboxRepository{collection: collection}
+}
+
+// Enqueue adds an email to the outbox, due immediately
+func (r *EmailOutboxRepository) Enqueue(ctx context.Context, email *models.OutboxEmail) error {
+	now := time.Now()
+	email.Status = models.EmailStatusPending
+	email.NextAttemptAt = now
+	email.CreatedAt = now
+	email.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	email.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ClaimNext atomically claims the next due email for delivery, or returns nil if none is due.
+// Emails left in "sending" by a crashed worker are reclaimed once their lock expires.
+func (r *EmailOutboxRepository) ClaimNext(ctx context.Context, lockFor time.Duration) (*models.OutboxEmail, error) {
+	now := time.Now()
+	filter := bson.M{
+		"$or": []bson.M{
+			{"status": models.EmailStatusPending, "next_attempt_at": bson.M{"$lte": now}},
+			{"status": models.EmailStatusSending, "locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.EmailStatusSending,
+			"locked_until": now.Add(lockFor),
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var email models.OutboxEmail
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&email)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &email, nil
+}
+
+// MarkSent marks an email as delivered and drops its bodies, which may contain one-time codes
+func (r *EmailOutboxRepository) MarkSent(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": models.EmailStatusSent, "sent_at": now, "updated_at": now},
+		"$unset": bson.M{"html": "", "text": "", "locked_until": "", "last_error": ""},
+	})
+	return err
+}
+
+// MarkFailed records a failed attempt and schedules the next one, or moves the email to the dead-letter state
+func (r *EmailOutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := models.EmailStatusPending
+	if dead {
+		status = models.EmailStatusDead
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+			"updated_at":      time.Now(),
+		},
+		"$unset": bson.M{"locked_until": ""},
+	})
+	return err
+}
+
+// Retry puts a dead email back in the queue with a fresh set of attempts
+func (r *EmailOutboxRepository) Retry(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.EmailStatusDead}, bson.M{
+		"$set": bson.M{
+			"status":          models.EmailStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// GetByID gets an outbox email by ID
+func (r *EmailOutboxRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.OutboxEmail, error) {
+	var email models.OutboxEmail
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&email)
+	if err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+// List gets outbox emails, optionally filtered by status, newest first
+func (r *EmailOutboxRepository) List(ctx context.Context, status string, skip, limit int64) ([]models.OutboxEmail, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, statusFilter(status), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	emails := []models.OutboxEmail{}
+	if err = cursor.All(ctx, &emails); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// Count counts outbox emails, optionally filtered by status
+func (r *EmailOutboxRepository) Count(ctx context.Context, status string) (int64, error) {
+	return r.collection.CountDocuments(ctx, statusFilter(status))
+}
+
+// DeleteByRecipient deletes every email queued or sent to an address
+func (r *EmailOutboxRepository) DeleteByRecipient(ctx context.Context, to string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"to": to})
+	return err
+}
+
+// statusFilter matches every email, or only those with the given status
+func statusFilter(status string) bson.M {
+	if status == "" {
+		return bson.M{}
+	}
+	return bson.M{"status": status}
+}
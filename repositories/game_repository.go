@@ -17,6 +17,24 @@ type GameRepository struct {
 
 // NewGameRepository creates a new game repository
 func NewGameRepository(db *mongo.Database) *GameRepository {
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Bets and results are paged per user, newest first, and looked up per game and by time range
+	for _, name := range []string{"bets", "game_results"} {
+		db.Collection(name).Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+			{Keys: bson.D{{Key: "game_id", Value: 1}}},
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		})
+	}
+
+	// Settled rounds are paged newest first
+	db.Collection("games").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	})
+
 	return &GameRepository{
 		db: db,
 	}
@@ -61,6 +79,67 @@ func (r *GameRepository) GetCurrentGame(ctx context.Context) (*models.Game, erro
 	return &game, nil
 }
 
+// ListSettledRounds gets up to limit rounds that were drawn after a cursor, newest first.
+// Rounds that expired without a draw are left out.
+func (r *GameRepository) ListSettledRounds(ctx context.Context, after *models.Cursor, limit int64) ([]models.Game, error) {
+	query := bson.M{"status": "completed", "winning_ball_id": bson.M{"$exists": true}}
+	applyCursor(query, after)
+
+	opts := options.Find().SetSort(newestFirst).SetLimit(limit)
+
+	cursor, err := r.db.Collection("games").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var games []models.Game
+	if err = cursor.All(ctx, &games); err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
+
+// AggregateRoundBalls sums the bets on each ball of the given rounds, without identifying players
+func (r *GameRepository) AggregateRoundBalls(ctx context.Context, gameIDs []primitive.ObjectID) ([]models.RoundBallStats, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"game_id": bson.M{"$in": gameIDs}}},
+		{"$group": bson.M{
+			"_id":           bson.M{"game_id": "$game_id", "ball_id": "$ball_id", "user_id": "$user_id"},
+			"ball_name":     bson.M{"$first": "$ball_name"},
+			"basket_landed": bson.M{"$first": "$basket_landed"},
+			"bets":          bson.M{"$sum": 1},
+			"wagered":       bson.M{"$sum": "$bet_amount"},
+			"payouts":       bson.M{"$sum": "$win_amount"},
+		}},
+		{"$group": bson.M{
+			"_id":           bson.M{"game_id": "$_id.game_id", "ball_id": "$_id.ball_id"},
+			"ball_name":     bson.M{"$first": "$ball_name"},
+			"basket_landed": bson.M{"$first": "$basket_landed"},
+			"bets":          bson.M{"$sum": "$bets"},
+			"players":       bson.M{"$sum": 1},
+			"wagered":       bson.M{"$sum": "$wagered"},
+			"payouts":       bson.M{"$sum": "$payouts"},
+		}},
+		{"$addFields": bson.M{"game_id": "$_id.game_id", "ball_id": "$_id.ball_id"}},
+		{"$sort": bson.M{"ball_id": 1}},
+	}
+
+	cursor, err := r.db.Collection("game_results").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var balls []models.RoundBallStats
+	if err = cursor.All(ctx, &balls); err != nil {
+		return nil, err
+	}
+
+	return balls, nil
+}
+
 // UpdateGame updates a game
 func (r *GameRepository) UpdateGame(ctx context.Context, gameID primitive.ObjectID, updateData map[string]interface{}) error {
 	collection := r.db.Collection("games")
@@ -121,6 +200,70 @@ func (r *GameRepository) GetBetsByUserID(ctx context.Context, userID primitive.O
 	return bets, nil
 }
 
+// StreamBets calls fn for every bet matching a filter, oldest first, without loading them all
+func (r *GameRepository) StreamBets(ctx context.Context, filter *models.BetFilter, fn func(*models.Bet) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.Collection("bets").Find(ctx, betQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var bet models.Bet
+		if err := cursor.Decode(&bet); err != nil {
+			return err
+		}
+		if err := fn(&bet); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// ListBets gets up to limit bets matching a filter after a cursor, newest first
+func (r *GameRepository) ListBets(ctx context.Context, filter *models.BetFilter, after *models.Cursor, limit int64) ([]models.Bet, error) {
+	query := betQuery(filter)
+	applyCursor(query, after)
+
+	opts := options.Find().SetSort(newestFirst).SetLimit(limit)
+
+	cursor, err := r.db.Collection("bets").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bets []models.Bet
+	if err = cursor.All(ctx, &bets); err != nil {
+		return nil, err
+	}
+
+	return bets, nil
+}
+
+// betQuery builds the query for a bet filter
+func betQuery(filter *models.BetFilter) bson.M {
+	query := bson.M{}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+	if filter.GameID != nil {
+		query["game_id"] = *filter.GameID
+	}
+	if filter.BallID != nil {
+		query["ball_id"] = *filter.BallID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if createdAt := timeRange(filter.From, filter.To); createdAt != nil {
+		query["created_at"] = createdAt
+	}
+	return query
+}
+
 // UpdateBet updates a bet
 func (r *GameRepository) UpdateBet(ctx context.Context, betID primitive.ObjectID, updateData map[string]interface{}) error {
 	collection := r.db.Collection("bets")
@@ -162,6 +305,79 @@ func (r *GameRepository) GetGameResultsByUserID(ctx context.Context, userID prim
 	return results, nil
 }
 
+// StreamGameResults calls fn for every game result matching a filter, oldest first, without loading them all
+func (r *GameRepository) StreamGameResults(ctx context.Context, filter *models.GameResultFilter, fn func(*models.GameResult) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.db.Collection("game_results").Find(ctx, gameResultQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var result models.GameResult
+		if err := cursor.Decode(&result); err != nil {
+			return err
+		}
+		if err := fn(&result); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// ListGameResults gets up to limit game results matching a filter after a cursor, newest first
+func (r *GameRepository) ListGameResults(ctx context.Context, filter *models.GameResultFilter, after *models.Cursor, limit int64) ([]models.GameResult, error) {
+	query := gameResultQuery(filter)
+	applyCursor(query, after)
+
+	opts := options.Find().SetSort(newestFirst).SetLimit(limit)
+
+	cursor, err := r.db.Collection("game_results").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.GameResult
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// gameResultQuery builds the query for a game result filter
+func gameResultQuery(filter *models.GameResultFilter) bson.M {
+	query := bson.M{}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+	if filter.GameID != nil {
+		query["game_id"] = *filter.GameID
+	}
+	if filter.BallID != nil {
+		query["ball_id"] = *filter.BallID
+	}
+	if filter.Won != nil {
+		query["won"] = *filter.Won
+	}
+	switch filter.Outcome {
+	case models.OutcomeWon:
+		query["won"] = true
+	case models.OutcomePushed:
+		query["pushed"] = true
+	case models.OutcomeLost:
+		query["won"] = false
+		query["pushed"] = false
+	}
+	if createdAt := timeRange(filter.From, filter.To); createdAt != nil {
+		query["created_at"] = createdAt
+	}
+	return query
+}
+
 // GetGameResultsByGameID gets all game results for a specific game
 func (r *GameRepository) GetGameResultsByGameID(ctx context.Context, gameID primitive.ObjectID) ([]models.GameResult, error) {
 	collection := r.db.Collection("game_results")
@@ -217,150 +433,7 @@ func (r *GameRepository) UpdateHouseWallet(ctx context.Context, updateData map[s
 	return err
 }
 
-// GetGameStats gets game statistics
-func (r *GameRepository) GetGameStats(ctx context.Context) (map[string]interface{}, error) {
-	collection := r.db.Collection("games")
-
-	// Get total games count
-	totalGames, err := collection.CountDocuments(ctx, bson.M{})
-	if err != nil {
-		return nil, err
-	}
-
-	// Get completed games count
-	completedGames, err := collection.CountDocuments(ctx, bson.M{"status": "completed"})
-	if err != nil {
-		return nil, err
-	}
-
-	// Get active games count
-	activeGames, err := collection.CountDocuments(ctx, bson.M{"status": "active"})
-	if err != nil {
-		return nil, err
-	}
-
-	// Get total bets amount
-	betsCollection := r.db.Collection("bets")
-	pipeline := []bson.M{
-		{
-			"$group": bson.M{
-				"_id":   nil,
-				"total": bson.M{"$sum": "$amount"},
-			},
-		},
-	}
-
-	cursor, err := betsCollection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var result []bson.M
-	if err = cursor.All(ctx, &result); err != nil {
-		return nil, err
-	}
-
-	totalBetsAmount := 0.0
-	if len(result) > 0 {
-		if total, ok := result[0]["total"].(float64); ok {
-			totalBetsAmount = total
-		}
-	}
-
-	return map[string]interface{}{
-		"total_games":       totalGames,
-		"completed_games":   completedGames,
-		"active_games":      activeGames,
-		"total_bets_amount": totalBetsAmount,
-	}, nil
-}
-
-// GetUserGameStats gets game statistics for a specific user
-func (r *GameRepository) GetUserGameStats(ctx context.Context, userID primitive.ObjectID) (map[string]interface{}, error) {
-	betsCollection := r.db.Collection("bets")
-	resultsCollection := r.db.Collection("game_results")
-
-	// Get user's total bets
-	pipeline := []bson.M{
-		{"$match": bson.M{"user_id": userID}},
-		{
-			"$group": bson.M{
-				"_id":        nil,
-				"total_bets": bson.M{"$sum": "$amount"},
-				"bet_count":  bson.M{"$sum": 1},
-			},
-		},
-	}
-
-	cursor, err := betsCollection.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var betResult []bson.M
-	if err = cursor.All(ctx, &betResult); err != nil {
-		return nil, err
-	}
-
-	totalBets := 0.0
-	betCount := 0
-	if len(betResult) > 0 {
-		if total, ok := betResult[0]["total_bets"].(float64); ok {
-			totalBets = total
-		}
-		if count, ok := betResult[0]["bet_count"].(int32); ok {
-			betCount = int(count)
-		}
-	}
-
-	// Get user's total winnings
-	winPipeline := []bson.M{
-		{"$match": bson.M{"user_id": userID, "won": true}},
-		{
-			"$group": bson.M{
-				"_id":        nil,
-				"total_wins": bson.M{"$sum": "$profit"},
-				"win_count":  bson.M{"$sum": 1},
-			},
-		},
-	}
-
-	winCursor, err := resultsCollection.Aggregate(ctx, winPipeline)
-	if err != nil {
-		return nil, err
-	}
-	defer winCursor.Close(ctx)
-
-	var winResult []bson.M
-	if err = winCursor.All(ctx, &winResult); err != nil {
-		return nil, err
-	}
-
-	totalWins := 0.0
-	winCount := 0
-	if len(winResult) > 0 {
-		if wins, ok := winResult[0]["total_wins"].(float64); ok {
-			totalWins = wins
-		}
-		if count, ok := winResult[0]["win_count"].(int32); ok {
-			winCount = int(count)
-		}
-	}
-
-	// Calculate win rate
-	winRate := 0.0
-	if betCount > 0 {
-		winRate = float64(winCount) / float64(betCount) * 100
-	}
-
-	return map[string]interface{}{
-		"total_bets": totalBets,
-		"bet_count":  betCount,
-		"total_wins": totalWins,
-		"win_count":  winCount,
-		"win_rate":   winRate,
-		"net_profit": totalWins - totalBets,
-	}, nil
+// CountGames counts the games with a status
+func (r *GameRepository) CountGames(ctx context.Context, status string) (int64, error) {
+	return r.db.Collection("games").CountDocuments(ctx, bson.M{"status": status})
 }
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationRetention is how long notifications are kept before MongoDB expires them
+const NotificationRetention = 90 * 24 * time.Hour
+
+// NotificationRepository handles in-app notifications
+type NotificationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *mongo.Database) *NotificationRepository {
+	collection := db.Collection("notifications")
+
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Create index for a user's notification feed
+	userIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+
+	// Create index for unread counts
+	unreadIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}},
+	}
+
+	// Expire old notifications
+	ttlIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(NotificationRetention.Seconds())),
+	}
+
+	collection.Indexes().CreateMany(ctx, []mongo.IndexModel{userIndex, unreadIndex, ttlIndex})
+
+	return &NotificationRepository{collection: collection}
+}
+
+// Create creates a new notification
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	notification.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, notification)
+	if err != nil {
+		return err
+	}
+
+	notification.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ListByUser gets a user's notifications, newest first, optionally only unread ones or one category
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, category string, unreadOnly bool, skip, limit int64) ([]models.Notification, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, notificationFilter(userID, category, unreadOnly), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// CountByUser counts a user's notifications, optionally only unread ones or one category
+func (r *NotificationRepository) CountByUser(ctx context.Context, userID primitive.ObjectID, category string, unreadOnly bool) (int64, error) {
+	return r.collection.CountDocuments(ctx, notificationFilter(userID, category, unreadOnly))
+}
+
+// MarkRead marks a user's notifications as read, all of them when ids is empty, and returns how many changed
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	filter := bson.M{"user_id": userID, "read": false}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{"read": true, "read_at": time.Now()},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// Exists checks if a notification belongs to a user
+func (r *NotificationRepository) Exists(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id, "user_id": userID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteByUser deletes every notification of a user
+func (r *NotificationRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// notificationFilter builds the query for a user's notifications
+func notificationFilter(userID primitive.ObjectID, category string, unreadOnly bool) bson.M {
+	filter := bson.M{"user_id": userID}
+	if category != "" {
+		filter["category"] = category
+	}
+	if unreadOnly {
+		filter["read"] = false
+	}
+	return filter
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/HSouheil/bucketball_backend/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -47,12 +48,33 @@ func (r *OTPRepository) GetLatestByEmailAndType(ctx context.Context, email, otpT
 	return &otp, nil
 }
 
-// MarkAsUsed marks an OTP as used
-func (r *OTPRepository) MarkAsUsed(ctx context.Context, email, code, otpType string) error {
+// ClaimAttempt counts a verification attempt against an unused OTP that has attempts left,
+// returning the updated OTP or mongo.ErrNoDocuments when none are left
+func (r *OTPRepository) ClaimAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) (*models.OTP, error) {
 	filter := bson.M{
-		"email": email,
-		"code":  code,
-		"type":  otpType,
+		"_id":      id,
+		"is_used":  false,
+		"attempts": bson.M{"$not": bson.M{"$gte": maxAttempts}}, // also matches codes issued before attempts were counted
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var otp models.OTP
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&otp); err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// MarkAsUsed marks an OTP as used, returning false if it already was
+func (r *OTPRepository) MarkAsUsed(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":     id,
+		"is_used": false,
 	}
 
 	update := bson.M{
@@ -61,8 +83,11 @@ func (r *OTPRepository) MarkAsUsed(ctx context.Context, email, code, otpType str
 		},
 	}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
-	return err
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
 }
 
 // DeleteExpiredOTPs deletes all expired OTPs
@@ -77,6 +102,12 @@ func (r *OTPRepository) DeleteExpiredOTPs(ctx context.Context) error {
 	return err
 }
 
+// DeleteByEmail deletes every OTP sent to an email
+func (r *OTPRepository) DeleteByEmail(ctx context.Context, email string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"email": email})
+	return err
+}
+
 // InvalidateOldOTPs invalidates all old OTPs for an email and type
 func (r *OTPRepository) InvalidateOldOTPs(ctx context.Context, email, otpType string) error {
 	filter := bson.M{
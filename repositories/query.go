@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// timeRange builds an inclusive range condition, or returns nil when neither bound is set
+func timeRange(from, to *time.Time) bson.M {
+	condition := bson.M{}
+	if from != nil {
+		condition["$gte"] = *from
+	}
+	if to != nil {
+		condition["$lte"] = *to
+	}
+	if len(condition) == 0 {
+		return nil
+	}
+	return condition
+}
+
+// applyCursor restricts a newest-first query to the items after a cursor
+func applyCursor(query bson.M, cursor *models.Cursor) {
+	if cursor == nil {
+		return
+	}
+	query["$or"] = bson.A{
+		bson.M{"created_at": bson.M{"$lt": cursor.CreatedAt}},
+		bson.M{"created_at": cursor.CreatedAt, "_id": bson.M{"$lt": cursor.ID}},
+	}
+}
+
+// newestFirst sorts by creation time, then ID, so cursors are stable
+var newestFirst = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
@@ -0,0 +1,356 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// referralProgramStateID is the ID of the document holding the program version counter and the active version
+const referralProgramStateID = "referral_program"
+
+// referralProgramState allocates program versions and records the active one. Keeping both in
+// one document makes publishing and activation single atomic writes.
+type referralProgramState struct {
+	LastVersion   int `bson:"last_version"`
+	ActiveVersion int `bson:"active_version"`
+}
+
+// ReferralRepository handles referral commission database operations
+type ReferralRepository struct {
+	collection *mongo.Collection
+	programs   *mongo.Collection
+	state      *mongo.Collection
+}
+
+// NewReferralRepository creates a new referral repository
+func NewReferralRepository(db *mongo.Database) *ReferralRepository {
+	collection := db.Collection("referral_commissions")
+
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Commissions are always listed per referrer, newest first
+	referrerIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "referrer_id", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+
+	referredUserIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "referred_user_id", Value: 1}},
+	}
+
+	// Exports scan commissions by time range
+	createdAtIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: -1}},
+	}
+
+	collection.Indexes().CreateMany(ctx, []mongo.IndexModel{referrerIndex, referredUserIndex, createdAtIndex})
+
+	// Program versions are unique so concurrent publishes cannot share a version
+	programs := db.Collection("referral_programs")
+	versionIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	programs.Indexes().CreateOne(ctx, versionIndex)
+
+	repo := &ReferralRepository{collection: collection, programs: programs, state: db.Collection("referral_program_state")}
+	repo.initProgramState(ctx)
+
+	return repo
+}
+
+// initProgramState makes sure the version counter is not behind the stored programs, and takes
+// the active version from the programs' is_active flag when the state does not have one yet
+func (r *ReferralRepository) initProgramState(ctx context.Context) {
+	var latest, active struct {
+		Version int `bson:"version"`
+	}
+	newest := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	r.programs.FindOne(ctx, bson.M{}, newest).Decode(&latest)
+	r.programs.FindOne(ctx, bson.M{"is_active": true}, newest).Decode(&active)
+
+	upsert := options.Update().SetUpsert(true)
+	if _, err := r.state.UpdateOne(ctx, bson.M{"_id": referralProgramStateID},
+		bson.M{"$max": bson.M{"last_version": latest.Version}, "$setOnInsert": bson.M{"active_version": active.Version}}, upsert); err != nil {
+		fmt.Printf("Warning: failed to initialize referral program state: %v\n", err)
+	}
+}
+
+// activeProgramVersion gets the active program version, 0 if none has been published
+func (r *ReferralRepository) activeProgramVersion(ctx context.Context) (int, error) {
+	var state referralProgramState
+	err := r.state.FindOne(ctx, bson.M{"_id": referralProgramStateID}).Decode(&state)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+	return state.ActiveVersion, nil
+}
+
+// CreateCommission stores a new referral commission record
+func (r *ReferralRepository) CreateCommission(ctx context.Context, commission *models.ReferralCommission) error {
+	result, err := r.collection.InsertOne(ctx, commission)
+	if err != nil {
+		return err
+	}
+
+	commission.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ListByReferrer gets the commissions earned by a referrer with pagination
+func (r *ReferralRepository) ListByReferrer(ctx context.Context, referrerID primitive.ObjectID, skip, limit int64) ([]models.ReferralCommission, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"referrer_id": referrerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	commissions := []models.ReferralCommission{}
+	if err = cursor.All(ctx, &commissions); err != nil {
+		return nil, err
+	}
+
+	return commissions, nil
+}
+
+// StreamCommissions calls fn for every commission matching a filter, oldest first, without loading them all
+func (r *ReferralRepository) StreamCommissions(ctx context.Context, filter *models.CommissionFilter, fn func(*models.ReferralCommission) error) error {
+	query := bson.M{}
+	if filter.ReferrerID != nil {
+		query["referrer_id"] = *filter.ReferrerID
+	}
+	if filter.ReferredUserID != nil {
+		query["referred_user_id"] = *filter.ReferredUserID
+	}
+	if filter.Source != "" {
+		query["source"] = filter.Source
+	}
+	if createdAt := timeRange(filter.From, filter.To); createdAt != nil {
+		query["created_at"] = createdAt
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var commission models.ReferralCommission
+		if err := cursor.Decode(&commission); err != nil {
+			return err
+		}
+		if err := fn(&commission); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// CountByReferrer counts the commissions earned by a referrer
+func (r *ReferralRepository) CountByReferrer(ctx context.Context, referrerID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"referrer_id": referrerID})
+}
+
+// CountActiveReferrals counts referred users that generated a commission since the given time
+func (r *ReferralRepository) CountActiveReferrals(ctx context.Context, referrerID primitive.ObjectID, since time.Time) (int64, error) {
+	filter := bson.M{
+		"referrer_id": referrerID,
+		"status":      "completed",
+		"created_at":  bson.M{"$gte": since},
+	}
+
+	ids, err := r.collection.Distinct(ctx, "referred_user_id", filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(ids)), nil
+}
+
+// SumByReferredUser totals the completed commissions a referrer earned from each referred user
+func (r *ReferralRepository) SumByReferredUser(ctx context.Context, referrerID primitive.ObjectID, referredUserIDs []primitive.ObjectID) (map[primitive.ObjectID]float64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"referrer_id":      referrerID,
+			"referred_user_id": bson.M{"$in": referredUserIDs},
+			"status":           "completed",
+		}},
+		{"$group": bson.M{
+			"_id":   "$referred_user_id",
+			"total": bson.M{"$sum": "$commission_amount"},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Total float64            `bson:"total"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[primitive.ObjectID]float64, len(rows))
+	for _, row := range rows {
+		totals[row.ID] = row.Total
+	}
+
+	return totals, nil
+}
+
+// GetEarningsOverTime groups a referrer's completed commissions into day or month buckets
+func (r *ReferralRepository) GetEarningsOverTime(ctx context.Context, referrerID primitive.ObjectID, since time.Time, granularity string) ([]models.ReferralEarningsPoint, error) {
+	format := "%Y-%m-%d"
+	if granularity == "month" {
+		format = "%Y-%m"
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"referrer_id": referrerID,
+			"status":      "completed",
+			"created_at":  bson.M{"$gte": since},
+		}},
+		{"$group": bson.M{
+			"_id":    bson.M{"$dateToString": bson.M{"format": format, "date": "$created_at"}},
+			"amount": bson.M{"$sum": "$commission_amount"},
+			"count":  bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.M{"_id": 1}},
+		{"$project": bson.M{"_id": 0, "period": "$_id", "amount": 1, "count": 1}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	points := []models.ReferralEarningsPoint{}
+	if err = cursor.All(ctx, &points); err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// GetActiveProgram gets the active referral program, or nil if none has been published
+func (r *ReferralRepository) GetActiveProgram(ctx context.Context) (*models.ReferralProgram, error) {
+	version, err := r.activeProgramVersion(ctx)
+	if err != nil || version == 0 {
+		return nil, err
+	}
+
+	var program models.ReferralProgram
+	if err := r.programs.FindOne(ctx, bson.M{"version": version}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	program.IsActive = true
+	return &program, nil
+}
+
+// GetProgramByVersion gets a referral program by version
+func (r *ReferralRepository) GetProgramByVersion(ctx context.Context, version int) (*models.ReferralProgram, error) {
+	var program models.ReferralProgram
+	err := r.programs.FindOne(ctx, bson.M{"version": version}).Decode(&program)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := r.activeProgramVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	program.IsActive = program.Version == active
+	return &program, nil
+}
+
+// ListPrograms gets every referral program version, newest first
+func (r *ReferralRepository) ListPrograms(ctx context.Context) ([]models.ReferralProgram, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.programs.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	programs := []models.ReferralProgram{}
+	if err = cursor.All(ctx, &programs); err != nil {
+		return nil, err
+	}
+
+	active, err := r.activeProgramVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range programs {
+		programs[i].IsActive = programs[i].Version == active
+	}
+
+	return programs, nil
+}
+
+// CreateProgram stores a new referral program under the next version number and makes it the active
+// one, unless a newer version was published concurrently
+func (r *ReferralRepository) CreateProgram(ctx context.Context, program *models.ReferralProgram) error {
+	var state referralProgramState
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := r.state.FindOneAndUpdate(ctx, bson.M{"_id": referralProgramStateID},
+		bson.M{"$inc": bson.M{"last_version": 1}}, opts).Decode(&state)
+	if err != nil {
+		return err
+	}
+
+	program.Version = state.LastVersion
+	program.CreatedAt = time.Now()
+
+	result, err := r.programs.InsertOne(ctx, program)
+	if err != nil {
+		return err
+	}
+	program.ID = result.InsertedID.(primitive.ObjectID)
+	program.IsActive = true
+
+	_, err = r.state.UpdateOne(ctx, bson.M{"_id": referralProgramStateID}, bson.M{"$max": bson.M{"active_version": program.Version}})
+	return err
+}
+
+// ActivateProgram makes a referral program version the active one
+func (r *ReferralRepository) ActivateProgram(ctx context.Context, version int) error {
+	count, err := r.programs.CountDocuments(ctx, bson.M{"version": version})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	_, err = r.state.UpdateOne(ctx, bson.M{"_id": referralProgramStateID},
+		bson.M{"$set": bson.M{"active_version": version}}, options.Update().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RoleRepository handles role database operations
+type RoleRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRoleRepository creates a new role repository and seeds the built-in roles
+func NewRoleRepository(db *mongo.Database) *RoleRepository {
+	collection := db.Collection("roles")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Create indexes
+	nameIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	collection.Indexes().CreateOne(ctx, nameIndex)
+
+	repo := &RoleRepository{collection: collection}
+	repo.seedDefaultRoles(ctx)
+
+	return repo
+}
+
+// seedDefaultRoles inserts the built-in roles that do not exist yet. Existing roles are left
+// untouched so permission changes made through the API survive restarts.
+func (r *RoleRepository) seedDefaultRoles(ctx context.Context) {
+	now := time.Now()
+	for _, role := range models.DefaultRoles() {
+		role.CreatedAt = now
+		role.UpdatedAt = now
+		opts := options.Update().SetUpsert(true)
+		r.collection.UpdateOne(ctx, bson.M{"name": role.Name}, bson.M{"$setOnInsert": role}, opts)
+	}
+}
+
+// GetByName gets a role by name, or nil if it does not exist
+func (r *RoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := r.collection.FindOne(ctx, bson.M{"name": name}).Decode(&role)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// List gets all roles sorted by name
+func (r *RoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	roles := []models.Role{}
+	if err = cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// Create creates a new role
+func (r *RoleRepository) Create(ctx context.Context, role *models.Role) error {
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	role.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Update updates a role by name
+func (r *RoleRepository) Update(ctx context.Context, name string, updateData map[string]interface{}) error {
+	updateData["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{"$set": updateData})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete deletes a role by name
+func (r *RoleRepository) Delete(ctx context.Context, name string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	return err
+}
@@ -0,0 +1,458 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StatsRepository handles the daily stats rollups
+type StatsRepository struct {
+	db *mongo.Database
+}
+
+// NewStatsRepository creates a new stats repository
+func NewStatsRepository(db *mongo.Database) *StatsRepository {
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// One rollup per day, and per player and day; the rebuild merges on these keys
+	db.Collection("daily_stats").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "day", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	db.Collection("daily_user_stats").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "day", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "day", Value: 1}}},
+	})
+
+	return &StatsRepository{
+		db: db,
+	}
+}
+
+// AddDay adds a round's totals to the global rollup of a day
+func (r *StatsRepository) AddDay(ctx context.Context, day time.Time, delta *models.DailyStats) error {
+	update := bson.M{
+		"$inc": bson.M{
+			"rounds":     delta.Rounds,
+			"bets":       delta.Bets,
+			"wins":       delta.Wins,
+			"pushes":     delta.Pushes,
+			"players":    delta.Players,
+			"wagered":    delta.Wagered,
+			"payouts":    delta.Payouts,
+			"admin_skim": delta.AdminSkim,
+		},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := r.db.Collection("daily_stats").UpdateOne(ctx, bson.M{"day": day}, update, opts)
+	return err
+}
+
+// AddUserDay adds a player's round totals to their rollup of a day, continuing the day's streaks
+// with those of the round. It reports whether the rollup was created, i.e. whether this is the
+// player's first settled round of the day.
+func (r *StatsRepository) AddUserDay(ctx context.Context, userID primitive.ObjectID, day time.Time, delta *models.DailyUserStats) (bool, error) {
+	set := bson.M{
+		"rounds":     addTo("rounds", delta.Rounds),
+		"bets":       addTo("bets", delta.Bets),
+		"wins":       addTo("wins", delta.Wins),
+		"pushes":     addTo("pushes", delta.Pushes),
+		"wagered":    addTo("wagered", delta.Wagered),
+		"payouts":    addTo("payouts", delta.Payouts),
+		"profit":     addTo("profit", delta.Profit),
+		"streaks":    continueStreaks(delta.Streaks),
+		"updated_at": "$$NOW",
+	}
+	for ballID, ball := range delta.Balls {
+		prefix := "balls." + ballID + "."
+		set[prefix+"bets"] = addTo(prefix+"bets", ball.Bets)
+		set[prefix+"wins"] = addTo(prefix+"wins", ball.Wins)
+		set[prefix+"pushes"] = addTo(prefix+"pushes", ball.Pushes)
+		set[prefix+"wagered"] = addTo(prefix+"wagered", ball.Wagered)
+		set[prefix+"payouts"] = addTo(prefix+"payouts", ball.Payouts)
+		set[prefix+"profit"] = addTo(prefix+"profit", ball.Profit)
+	}
+	if delta.BiggestWin != nil {
+		// Ties keep the earlier win
+		set["biggest_win"] = bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{delta.BiggestWin.Profit, bson.M{"$ifNull": bson.A{"$biggest_win.profit", math.Inf(-1)}}}},
+			bson.M{"$literal": delta.BiggestWin},
+			"$biggest_win",
+		}}
+	}
+
+	// A pipeline update reads and extends the streaks atomically
+	update := mongo.Pipeline{{{Key: "$set", Value: set}}}
+	opts := options.Update().SetUpsert(true)
+	result, err := r.db.Collection("daily_user_stats").UpdateOne(ctx, bson.M{"user_id": userID, "day": day}, update, opts)
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// ListUserDays gets a player's daily rollups from the day of from through the day of to (both optional), oldest first
+func (r *StatsRepository) ListUserDays(ctx context.Context, userID primitive.ObjectID, from, to *time.Time) ([]models.DailyUserStats, error) {
+	query := bson.M{"user_id": userID}
+	if days := timeRange(from, to); days != nil {
+		query["day"] = days
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "day", Value: 1}})
+
+	cursor, err := r.db.Collection("daily_user_stats").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var days []models.DailyUserStats
+	if err = cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// addTo builds a pipeline expression adding an amount to a field that may not exist yet
+func addTo(field string, amount interface{}) bson.M {
+	return bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + field, 0}}, amount}}
+}
+
+// continueStreaks builds a pipeline expression combining the stored streaks with those of the
+// bets settled after them, the same way as models.StatsStreaks.Then
+func continueStreaks(next models.StatsStreaks) bson.M {
+	// The stored closing run continues into the next opening run if both are wins or both losses
+	var joined interface{} = 0
+	switch {
+	case next.Lead > 0:
+		joined = bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$$s.trail", 0}}, bson.M{"$add": bson.A{"$$s.trail", next.Lead}}, 0}}
+	case next.Lead < 0:
+		joined = bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{"$$s.trail", 0}}, bson.M{"$add": bson.A{"$$s.trail", next.Lead}}, 0}}
+	}
+
+	storedSingleRun := bson.M{"$eq": bson.A{bson.M{"$abs": "$$s.lead"}, "$$s.bets"}}
+	joinedRun := bson.M{"$ne": bson.A{"$$joined", 0}}
+
+	var trail interface{} = next.Trail
+	if next.Lead == next.Bets || next.Lead == -next.Bets {
+		trail = bson.M{"$cond": bson.A{joinedRun, "$$joined", next.Trail}}
+	}
+
+	combined := bson.M{
+		"bets":         bson.M{"$add": bson.A{"$$s.bets", next.Bets}},
+		"lead":         bson.M{"$cond": bson.A{bson.M{"$and": bson.A{storedSingleRun, joinedRun}}, "$$joined", "$$s.lead"}},
+		"trail":        trail,
+		"longest_win":  bson.M{"$max": bson.A{"$$s.longest_win", next.LongestWin, "$$joined"}},
+		"longest_loss": bson.M{"$max": bson.A{"$$s.longest_loss", next.LongestLoss, bson.M{"$multiply": bson.A{"$$joined", -1}}}},
+	}
+
+	empty := bson.M{"bets": 0, "lead": 0, "trail": 0, "longest_win": 0, "longest_loss": 0}
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"s": bson.M{"$ifNull": bson.A{"$streaks", empty}}},
+		"in": bson.M{"$let": bson.M{
+			"vars": bson.M{"joined": joined},
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$$s.bets", 0}},
+				bson.M{"$literal": next},
+				combined,
+			}},
+		}},
+	}}
+}
+
+// SumStats sums the global rollups of every day
+func (r *StatsRepository) SumStats(ctx context.Context) (*models.DailyStats, error) {
+	pipeline := []bson.M{
+		{"$group": bson.M{
+			"_id":        nil,
+			"rounds":     bson.M{"$sum": "$rounds"},
+			"bets":       bson.M{"$sum": "$bets"},
+			"wins":       bson.M{"$sum": "$wins"},
+			"pushes":     bson.M{"$sum": "$pushes"},
+			"wagered":    bson.M{"$sum": "$wagered"},
+			"payouts":    bson.M{"$sum": "$payouts"},
+			"admin_skim": bson.M{"$sum": "$admin_skim"},
+		}},
+		{"$unset": "_id"},
+	}
+
+	var totals models.DailyStats
+	if err := r.aggregateOne(ctx, "daily_stats", pipeline, &totals); err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// Rebuild recomputes the rollups of the days in [from, to) from the settled rounds and results
+func (r *StatsRepository) Rebuild(ctx context.Context, from, to time.Time) error {
+	days := periodRange(from, to)
+
+	// Drop the days first so rollups of days without rounds anymore do not linger
+	if _, err := r.db.Collection("daily_stats").DeleteMany(ctx, bson.M{"day": days}); err != nil {
+		return err
+	}
+	if _, err := r.db.Collection("daily_user_stats").DeleteMany(ctx, bson.M{"day": days}); err != nil {
+		return err
+	}
+
+	day := bucketStart("$created_at", models.AnalyticsGranularityDay)
+	userTotals := bson.M{
+		"_id":     bson.M{"user_id": "$user_id", "day": day},
+		"bets":    bson.M{"$sum": 1},
+		"wins":    bson.M{"$sum": bson.M{"$cond": bson.A{"$won", 1, 0}}},
+		"pushes":  bson.M{"$sum": bson.M{"$cond": bson.A{"$pushed", 1, 0}}},
+		"wagered": bson.M{"$sum": "$bet_amount"},
+		"payouts": bson.M{"$sum": "$win_amount"},
+	}
+
+	// Per-player rollups, summed per ball first for the ball breakdown
+	userPipeline := []bson.M{
+		{"$match": bson.M{"created_at": days}},
+		{"$group": bson.M{
+			"_id":     bson.M{"user_id": "$user_id", "day": day, "ball_id": "$ball_id"},
+			"games":   bson.M{"$addToSet": "$game_id"},
+			"bets":    bson.M{"$sum": 1},
+			"wins":    bson.M{"$sum": bson.M{"$cond": bson.A{"$won", 1, 0}}},
+			"pushes":  bson.M{"$sum": bson.M{"$cond": bson.A{"$pushed", 1, 0}}},
+			"wagered": bson.M{"$sum": "$bet_amount"},
+			"payouts": bson.M{"$sum": "$win_amount"},
+			"profit":  bson.M{"$sum": "$profit"},
+		}},
+		{"$group": bson.M{
+			"_id":     bson.M{"user_id": "$_id.user_id", "day": "$_id.day"},
+			"games":   bson.M{"$push": "$games"},
+			"bets":    bson.M{"$sum": "$bets"},
+			"wins":    bson.M{"$sum": "$wins"},
+			"pushes":  bson.M{"$sum": "$pushes"},
+			"wagered": bson.M{"$sum": "$wagered"},
+			"payouts": bson.M{"$sum": "$payouts"},
+			"profit":  bson.M{"$sum": "$profit"},
+			"balls": bson.M{"$push": bson.M{
+				"k": bson.M{"$toString": "$_id.ball_id"},
+				"v": bson.M{
+					"bets":    "$bets",
+					"wins":    "$wins",
+					"pushes":  "$pushes",
+					"wagered": "$wagered",
+					"payouts": "$payouts",
+					"profit":  "$profit",
+				},
+			}},
+		}},
+		{"$project": bson.M{
+			"_id":     0,
+			"user_id": "$_id.user_id",
+			"day":     "$_id.day",
+			"rounds": bson.M{"$size": bson.M{"$reduce": bson.M{
+				"input":        "$games",
+				"initialValue": bson.A{},
+				"in":           bson.M{"$setUnion": bson.A{"$$value", "$$this"}},
+			}}},
+			"bets":       1,
+			"wins":       1,
+			"pushes":     1,
+			"wagered":    1,
+			"payouts":    1,
+			"profit":     1,
+			"balls":      bson.M{"$arrayToObject": "$balls"},
+			"updated_at": "$$NOW",
+		}},
+		mergeInto("daily_user_stats", "user_id", "day"),
+	}
+	if err := r.run(ctx, "game_results", userPipeline); err != nil {
+		return err
+	}
+
+	// Each player's most profitable win of the day, the earliest one on ties
+	biggestWinPipeline := []bson.M{
+		{"$match": bson.M{"created_at": days, "won": true}},
+		{"$group": bson.M{
+			"_id": bson.M{"user_id": "$user_id", "day": day},
+			"biggest_win": bson.M{"$top": bson.M{
+				"sortBy": bson.D{{Key: "profit", Value: -1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+				"output": "$$ROOT",
+			}},
+		}},
+		{"$project": bson.M{
+			"_id":         0,
+			"user_id":     "$_id.user_id",
+			"day":         "$_id.day",
+			"biggest_win": 1,
+		}},
+		mergeInto("daily_user_stats", "user_id", "day"),
+	}
+	if err := r.run(ctx, "game_results", biggestWinPipeline); err != nil {
+		return err
+	}
+
+	if err := r.rebuildStreaks(ctx, days); err != nil {
+		return err
+	}
+
+	// Global bet totals, grouped per player first to count the day's distinct players
+	betPipeline := []bson.M{
+		{"$match": bson.M{"created_at": days}},
+		{"$group": userTotals},
+		{"$group": bson.M{
+			"_id":     "$_id.day",
+			"bets":    bson.M{"$sum": "$bets"},
+			"wins":    bson.M{"$sum": "$wins"},
+			"pushes":  bson.M{"$sum": "$pushes"},
+			"players": bson.M{"$sum": 1},
+			"wagered": bson.M{"$sum": "$wagered"},
+			"payouts": bson.M{"$sum": "$payouts"},
+		}},
+		{"$project": bson.M{
+			"_id":        0,
+			"day":        "$_id",
+			"bets":       1,
+			"wins":       1,
+			"pushes":     1,
+			"players":    1,
+			"wagered":    1,
+			"payouts":    1,
+			"updated_at": "$$NOW",
+		}},
+		mergeInto("daily_stats", "day"),
+	}
+	if err := r.run(ctx, "game_results", betPipeline); err != nil {
+		return err
+	}
+
+	// Global round totals; rounds that expired without a draw are not settled
+	roundPipeline := []bson.M{
+		{"$match": bson.M{
+			"status":          "completed",
+			"winning_ball_id": bson.M{"$exists": true},
+			"completed_at":    days,
+		}},
+		{"$group": bson.M{
+			"_id":        bucketStart("$completed_at", models.AnalyticsGranularityDay),
+			"rounds":     bson.M{"$sum": 1},
+			"admin_skim": bson.M{"$sum": "$admin_skim"},
+		}},
+		{"$project": bson.M{
+			"_id":        0,
+			"day":        "$_id",
+			"rounds":     1,
+			"admin_skim": 1,
+			"updated_at": "$$NOW",
+		}},
+		mergeInto("daily_stats", "day"),
+	}
+	return r.run(ctx, "games", roundPipeline)
+}
+
+// rebuildStreaks replays the players' results of the given days in settlement order to store
+// each player's streaks per day
+func (r *StatsRepository) rebuildStreaks(ctx context.Context, days bson.M) error {
+	// Descending users and ascending times walk the (user_id, created_at, _id) index backwards
+	opts := options.Find().
+		SetSort(bson.D{{Key: "user_id", Value: -1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"user_id": 1, "created_at": 1, "won": 1, "pushed": 1})
+
+	cursor, err := r.db.Collection("game_results").Find(ctx, bson.M{"created_at": days}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	collection := r.db.Collection("daily_user_stats")
+	var writes []mongo.WriteModel
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		_, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		writes = writes[:0]
+		return err
+	}
+
+	var current struct {
+		userID  primitive.ObjectID
+		day     time.Time
+		streaks models.StatsStreaks
+	}
+	store := func() error {
+		if current.streaks.Bets == 0 {
+			return nil
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"user_id": current.userID, "day": current.day}).
+			SetUpdate(bson.M{"$set": bson.M{"streaks": current.streaks}}))
+		if len(writes) >= 1000 {
+			return flush()
+		}
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var result models.GameResult
+		if err := cursor.Decode(&result); err != nil {
+			return err
+		}
+		resultDay := result.CreatedAt.UTC().Truncate(24 * time.Hour)
+		if result.UserID != current.userID || !resultDay.Equal(current.day) {
+			if err := store(); err != nil {
+				return err
+			}
+			current.userID = result.UserID
+			current.day = resultDay
+			current.streaks = models.StatsStreaks{}
+		}
+		current.streaks = current.streaks.Then(models.ResultStreaks(&result))
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := store(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// aggregateOne runs a pipeline that yields at most one document and decodes it into out
+func (r *StatsRepository) aggregateOne(ctx context.Context, collection string, pipeline []bson.M, out interface{}) error {
+	cursor, err := r.db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	if cursor.Next(ctx) {
+		return cursor.Decode(out)
+	}
+	return cursor.Err()
+}
+
+// run runs a pipeline for its side effects, such as a $merge
+func (r *StatsRepository) run(ctx context.Context, collection string, pipeline []bson.M) error {
+	cursor, err := r.db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// mergeInto builds a $merge stage that adds fields to the documents matching on keys, or inserts them
+func mergeInto(collection string, keys ...string) bson.M {
+	return bson.M{"$merge": bson.M{
+		"into":           collection,
+		"on":             keys,
+		"whenMatched":    "merge",
+		"whenNotMatched": "insert",
+	}}
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TransactionRepository handles financial transaction database operations
+type TransactionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTransactionRepository creates a new transaction repository
+func NewTransactionRepository(db *mongo.Database) *TransactionRepository {
+	collection := db.Collection("transactions")
+
+	// Create indexes
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Transactions are paged per user, newest first
+	userIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	}
+
+	// Admin adjustments are listed per admin
+	adminIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "admin_id", Value: 1}, {Key: "created_at", Value: -1}},
+		Options: options.Index().SetSparse(true),
+	}
+
+	collection.Indexes().CreateMany(ctx, []mongo.IndexModel{userIndex, adminIndex})
+
+	return &TransactionRepository{collection: collection}
+}
+
+// Create stores a new transaction
+func (r *TransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	transaction.CreatedAt = time.Now()
+	transaction.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	transaction.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ListByUser gets a user's transactions with pagination, newest first
+func (r *TransactionRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, skip, limit int64) ([]models.Transaction, error) {
+	return r.list(ctx, bson.M{"user_id": userID}, skip, limit)
+}
+
+// CountByUser counts a user's transactions
+func (r *TransactionRepository) CountByUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+// ListByAdmin gets the balance adjustments made by an admin with pagination, newest first
+func (r *TransactionRepository) ListByAdmin(ctx context.Context, adminID primitive.ObjectID, skip, limit int64) ([]models.Transaction, error) {
+	return r.list(ctx, bson.M{"admin_id": adminID}, skip, limit)
+}
+
+// CountByAdmin counts the balance adjustments made by an admin
+func (r *TransactionRepository) CountByAdmin(ctx context.Context, adminID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"admin_id": adminID})
+}
+
+// ListAfter gets up to limit transactions matching a filter after a cursor, newest first
+func (r *TransactionRepository) ListAfter(ctx context.Context, filter *models.TransactionFilter, after *models.Cursor, limit int64) ([]models.Transaction, error) {
+	query := bson.M{}
+	if filter.UserID != nil {
+		query["user_id"] = *filter.UserID
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if createdAt := timeRange(filter.From, filter.To); createdAt != nil {
+		query["created_at"] = createdAt
+	}
+	applyCursor(query, after)
+
+	opts := options.Find().SetSort(newestFirst).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []models.Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// list gets transactions matching a filter with pagination, newest first
+func (r *TransactionRepository) list(ctx context.Context, filter bson.M, skip, limit int64) ([]models.Transaction, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transactions := []models.Transaction{}
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/HSouheil/bucketball_backend/config"
@@ -43,7 +45,38 @@ func NewUserRepository(client *mongo.Client, cfg *config.Config) *UserRepository
 		Options: options.Index().SetUnique(true),
 	}
 
-	collection.Indexes().CreateMany(ctx, []mongo.IndexModel{emailIndex, usernameIndex, referralCodeIndex})
+	// Create index on referred_by for referral dashboards
+	referredByIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "referred_by", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+
+	// Create indexes for the admin user search filters and sort columns
+	createdAtIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "created_at", Value: -1}},
+	}
+	roleIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "role", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+	statusIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "is_active", Value: 1}, {Key: "is_email_verified", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+	countryIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "location.country", Value: 1}, {Key: "created_at", Value: -1}},
+	}
+	balanceIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "balance", Value: -1}},
+	}
+	referralEarningsIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "referral_earnings", Value: -1}},
+	}
+	updatedAtIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "updated_at", Value: -1}},
+	}
+
+	collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		emailIndex, usernameIndex, referralCodeIndex, referredByIndex,
+		createdAtIndex, roleIndex, statusIndex, countryIndex, balanceIndex, referralEarningsIndex, updatedAtIndex,
+	})
 
 	return &UserRepository{collection: collection}
 }
@@ -114,6 +147,71 @@ func (r *UserRepository) Update(ctx context.Context, id primitive.ObjectID, upda
 	return err
 }
 
+// Increment atomically adds the given amounts to numeric user fields
+func (r *UserRepository) Increment(ctx context.Context, id primitive.ObjectID, fields map[string]float64) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": fields, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	return err
+}
+
+// AdjustBalance atomically adds delta to a user's balance and returns the updated user.
+// A negative delta only applies if the balance covers it; otherwise mongo.ErrNoDocuments is returned.
+func (r *UserRepository) AdjustBalance(ctx context.Context, id primitive.ObjectID, delta float64) (*models.User, error) {
+	filter := bson.M{"_id": id}
+	if delta < 0 {
+		filter["balance"] = bson.M{"$gte": -delta}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$inc": bson.M{"balance": delta}, "$set": bson.M{"updated_at": time.Now()}},
+		opts,
+	).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ConsumeRecoveryCode removes a hashed two-factor recovery code from a user,
+// returning false if the user does not have that code
+func (r *UserRepository) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, codeHash string) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "two_factor_recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"two_factor_recovery_codes": codeHash}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Anonymize overwrites a user's personal fields in place and removes the given fields
+func (r *UserRepository) Anonymize(ctx context.Context, id primitive.ObjectID, replacements map[string]interface{}, removed []string) error {
+	replacements["updated_at"] = time.Now()
+
+	unset := bson.M{}
+	for _, field := range removed {
+		unset[field] = ""
+	}
+
+	update := bson.M{"$set": replacements}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
 // Delete deletes a user
 func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -145,3 +243,159 @@ func (r *UserRepository) List(ctx context.Context, skip, limit int64) ([]*models
 func (r *UserRepository) Count(ctx context.Context) (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{})
 }
+
+// Search gets users matching a filter with pagination, sorted by the filter's sort column
+func (r *UserRepository) Search(ctx context.Context, filter *models.UserFilter, skip, limit int64) ([]*models.User, error) {
+	order := 1
+	if filter.SortDesc {
+		order = -1
+	}
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+
+	// Sort on _id as well so pages stay stable when values tie
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: sortBy, Value: order}, {Key: "_id", Value: order}})
+
+	cursor, err := r.collection.Find(ctx, buildUserQuery(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*models.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Stream calls fn for every user matching a filter, in the filter's sort order, without loading them all
+func (r *UserRepository) Stream(ctx context.Context, filter *models.UserFilter, fn func(*models.User) error) error {
+	order := 1
+	if filter.SortDesc {
+		order = -1
+	}
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: sortBy, Value: order}, {Key: "_id", Value: order}})
+
+	cursor, err := r.collection.Find(ctx, buildUserQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// CountMatching counts users matching a filter
+func (r *UserRepository) CountMatching(ctx context.Context, filter *models.UserFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, buildUserQuery(filter))
+}
+
+// buildUserQuery converts a user filter into a Mongo query
+func buildUserQuery(filter *models.UserFilter) bson.M {
+	query := bson.M{}
+
+	// Emails and referral codes are stored lowercase, so their prefix match stays case-sensitive
+	// and can use the unique indexes; usernames keep their case and match case-insensitively
+	if filter.Search != "" {
+		prefix := "^" + regexp.QuoteMeta(strings.ToLower(filter.Search))
+		query["$or"] = []bson.M{
+			{"email": bson.M{"$regex": prefix}},
+			{"referral_code": bson.M{"$regex": prefix}},
+			{"username": bson.M{"$regex": "^" + regexp.QuoteMeta(filter.Search), "$options": "i"}},
+		}
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.IsActive != nil {
+		query["is_active"] = *filter.IsActive
+	}
+	if filter.IsEmailVerified != nil {
+		query["is_email_verified"] = *filter.IsEmailVerified
+	}
+	if filter.IsDeleted != nil {
+		query["deleted_at"] = bson.M{"$exists": *filter.IsDeleted}
+	}
+	if filter.Country != "" {
+		query["location.country"] = filter.Country
+	}
+	if filter.ReferredBy != nil {
+		query["referred_by"] = *filter.ReferredBy
+	}
+
+	balance := bson.M{}
+	if filter.MinBalance != nil {
+		balance["$gte"] = *filter.MinBalance
+	}
+	if filter.MaxBalance != nil {
+		balance["$lte"] = *filter.MaxBalance
+	}
+	if len(balance) > 0 {
+		query["balance"] = balance
+	}
+
+	createdAt := bson.M{}
+	if filter.CreatedFrom != nil {
+		createdAt["$gte"] = *filter.CreatedFrom
+	}
+	if filter.CreatedTo != nil {
+		createdAt["$lte"] = *filter.CreatedTo
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	return query
+}
+
+// ListByReferrer gets the users referred by a referrer with pagination
+func (r *UserRepository) ListByReferrer(ctx context.Context, referrerID primitive.ObjectID, skip, limit int64) ([]*models.User, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"referred_by": referrerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// CountByRole counts users assigned to a role
+func (r *UserRepository) CountByRole(ctx context.Context, role string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"role": role})
+}
+
+// CountByReferrer counts the users referred by a referrer
+func (r *UserRepository) CountByReferrer(ctx context.Context, referrerID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"referred_by": referrerID})
+}
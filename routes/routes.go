@@ -1,11 +1,12 @@
 package routes
 
 import (
-	"time"
+	"context"
 
 	"github.com/HSouheil/bucketball_backend/config"
 	"github.com/HSouheil/bucketball_backend/controllers"
 	"github.com/HSouheil/bucketball_backend/middleware"
+	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/HSouheil/bucketball_backend/repositories"
 	"github.com/HSouheil/bucketball_backend/services"
 	"github.com/labstack/echo/v4"
@@ -22,21 +23,54 @@ func SetupRoutes(e *echo.Echo, userRepo *repositories.UserRepository, authRepo *
 
 	// Initialize repositories
 	gameRepo := repositories.NewGameRepository(db)
+	referralRepo := repositories.NewReferralRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	transactionRepo := repositories.NewTransactionRepository(db)
+	outboxRepo := repositories.NewEmailOutboxRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	analyticsRepo := repositories.NewAnalyticsRepository(db)
+	statsRepo := repositories.NewStatsRepository(db)
 
 	// Initialize services
-	emailService := services.NewEmailService(&cfg.Email)
+	mailTransport := services.NewMailTransport(&cfg.Email)
+	emailService := services.NewEmailService(&cfg.Email, outboxRepo, mailTransport)
 	otpService := services.NewOTPService(otpRepo, emailService)
-	authService := services.NewAuthService(userRepo, authRepo, otpService)
-	userService := services.NewUserService(userRepo)
-	referralService := services.NewReferralService(userRepo)
-	paymentService := services.NewPaymentService(userRepo, referralService)
-	gameService := services.NewGameService(gameRepo, userRepo)
+	auditService := services.NewAuditService(auditRepo)
+	outboxService := services.NewEmailOutboxService(outboxRepo, emailService, auditService)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, emailService)
+	referralService := services.NewReferralService(userRepo, referralRepo, auditService, notificationService)
+	sessionService := services.NewSessionService(authRepo)
+	requestLimiter := services.NewRequestLimiter(authRepo, &cfg.RateLimit)
+	roleService := services.NewRoleService(roleRepo, userRepo, sessionService, auditService)
+	authService := services.NewAuthService(userRepo, authRepo, otpService, referralService, sessionService, roleService, auditService)
+	privacyService := services.NewPrivacyService(userRepo, gameRepo, transactionRepo, referralRepo, notificationRepo, otpRepo, outboxRepo, sessionService, auditService)
+	exportService := services.NewExportService(userRepo, gameRepo, referralRepo, auditService)
+	userService := services.NewUserService(userRepo, transactionRepo, sessionService, auditService, privacyService)
+	paymentService := services.NewPaymentService(userRepo, transactionRepo, referralService, notificationService)
+	analyticsService := services.NewAnalyticsService(analyticsRepo)
+	statsService := services.NewStatsService(statsRepo, gameRepo)
+	playerStatsService := services.NewPlayerStatsService(statsRepo)
+	gameService := services.NewGameService(gameRepo, userRepo, referralService, auditService, notificationService, statsService)
 
 	// Initialize controllers
 	authController := controllers.NewAuthController(authService, paymentService)
 	userController := controllers.NewUserController(userService)
 	adminController := controllers.NewAdminController(authService)
-	gameController := controllers.NewGameController(gameService)
+	gameController := controllers.NewGameController(gameService, playerStatsService)
+	referralController := controllers.NewReferralController(referralService)
+	sessionController := controllers.NewSessionController(sessionService)
+	twoFactorController := controllers.NewTwoFactorController(authService)
+	roleController := controllers.NewRoleController(roleService)
+	auditController := controllers.NewAuditController(auditService)
+	outboxController := controllers.NewEmailOutboxController(outboxService)
+	notificationController := controllers.NewNotificationController(notificationService)
+	privacyController := controllers.NewPrivacyController(privacyService)
+	exportController := controllers.NewExportController(exportService)
+	analyticsController := controllers.NewAnalyticsController(analyticsService)
+
+	// Deliver queued emails in the background
+	go outboxService.Run(context.Background())
 
 	// API v1 group
 	v1 := e.Group("/api")
@@ -49,33 +83,70 @@ func SetupRoutes(e *echo.Echo, userRepo *repositories.UserRepository, authRepo *
 	// Static file serving for uploads
 	e.Static("/uploads", "uploads")
 
+	// Development mailbox, only when emails are kept in memory outside production
+	if mailbox, ok := mailTransport.(*services.MemoryTransport); ok && cfg.App.Environment != "production" {
+		devController := controllers.NewDevController(mailbox)
+		dev := v1.Group("/dev")
+		dev.GET("/mailbox", devController.GetMailbox)
+		dev.DELETE("/mailbox", devController.ClearMailbox)
+	}
+
 	// Auth routes (public)
 	auth := v1.Group("/auth")
-	auth.POST("/register", authController.Register, middleware.RateLimitMiddleware(authRepo, 5, time.Minute))
-	auth.POST("/verify-email", authController.VerifyEmail, middleware.RateLimitMiddleware(authRepo, 10, time.Minute))
-	auth.POST("/resend-otp", authController.ResendOTP, middleware.RateLimitMiddleware(authRepo, 5, time.Minute))
-	auth.POST("/login", authController.Login, middleware.RateLimitMiddleware(authRepo, 10, time.Minute))
+	auth.POST("/register", authController.Register, middleware.RateLimit(requestLimiter, config.RateLimitRegister))
+	auth.POST("/verify-email", authController.VerifyEmail, middleware.RateLimit(requestLimiter, config.RateLimitVerifyEmail))
+	auth.POST("/resend-otp", authController.ResendOTP, middleware.RateLimit(requestLimiter, config.RateLimitResendOTP))
+	auth.POST("/login", authController.Login, middleware.RateLimit(requestLimiter, config.RateLimitLogin))
+	auth.POST("/2fa/verify", twoFactorController.VerifyLogin, middleware.RateLimit(requestLimiter, config.RateLimitTwoFactor))
+	auth.POST("/2fa/setup", twoFactorController.BeginChallengeSetup, middleware.RateLimit(requestLimiter, config.RateLimitTwoFactorSetup))
+	auth.POST("/refresh", authController.RefreshToken, middleware.RateLimit(requestLimiter, config.RateLimitRefresh))
 	auth.POST("/logout", authController.Logout, middleware.AuthMiddleware(authRepo))
+	auth.POST("/forgot-password", authController.ForgotPassword, middleware.RateLimit(requestLimiter, config.RateLimitForgotPassword))
+	auth.POST("/reset-password", authController.ResetPassword, middleware.RateLimit(requestLimiter, config.RateLimitResetPassword))
 
 	// User routes (protected)
 	users := v1.Group("/users")
 	users.Use(middleware.AuthMiddleware(authRepo))
-	users.Use(middleware.AuthRateLimitMiddleware(authRepo, 100, time.Hour))
+	users.Use(middleware.AuditMiddleware())
+	users.Use(middleware.RateLimit(requestLimiter, config.RateLimitUsers))
 
 	users.GET("/profile", authController.GetProfile)
 	users.PUT("/profile", authController.UpdateProfile)
 	users.GET("/referral-stats", authController.GetReferralStats)
+	users.GET("/referrals", referralController.GetReferralDashboard)
 	users.POST("/payment", authController.ProcessPayment)
+	users.GET("/transactions", authController.GetTransactions)
+	users.POST("/password", authController.ChangePassword)
+	users.POST("/email", authController.RequestEmailChange)
+	users.POST("/email/verify", authController.ConfirmEmailChange)
+	users.GET("/sessions", sessionController.GetSessions)
+	users.DELETE("/sessions", sessionController.RevokeAllSessions)
+	users.DELETE("/sessions/:id", sessionController.RevokeSession)
+	users.POST("/2fa/setup", twoFactorController.BeginSetup)
+	users.POST("/2fa/confirm", twoFactorController.ConfirmSetup)
+	users.POST("/2fa/disable", twoFactorController.Disable)
+	users.POST("/2fa/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+	users.GET("/notifications", notificationController.GetNotifications)
+	users.GET("/notifications/unread-count", notificationController.GetUnreadCount)
+	users.POST("/notifications/read", notificationController.MarkAllRead)
+	users.POST("/notifications/:id/read", notificationController.MarkRead)
+	users.GET("/notifications/preferences", notificationController.GetPreferences)
+	users.PUT("/notifications/preferences", notificationController.UpdatePreferences)
+	users.GET("/data-export", privacyController.ExportData)
+	users.DELETE("/account", privacyController.DeleteAccount)
 
 	// Game routes (protected)
 	games := v1.Group("/games")
 	games.Use(middleware.AuthMiddleware(authRepo))
-	games.Use(middleware.AuthRateLimitMiddleware(authRepo, 50, time.Hour))
+	games.Use(middleware.RateLimit(requestLimiter, config.RateLimitGames))
 
 	games.GET("/state", gameController.GetGameState)
 	games.POST("/bet", gameController.PlaceBet)
 	games.POST("/:id/play", gameController.PlayGame)
 	games.GET("/history", gameController.GetGameHistory)
+	games.GET("/bets", gameController.GetBets)
+	games.GET("/rounds", gameController.GetRounds)
+	games.GET("/rounds/:id", gameController.GetRound)
 	games.GET("/stats", gameController.GetUserGameStats)
 	games.GET("/balls", gameController.GetAvailableBalls)
 	games.GET("/baskets", gameController.GetAvailableBaskets)
@@ -83,21 +154,68 @@ func SetupRoutes(e *echo.Echo, userRepo *repositories.UserRepository, authRepo *
 	// Admin routes
 	admin := v1.Group("/admin")
 	admin.Use(middleware.AuthMiddleware(authRepo))
-	admin.Use(middleware.AdminMiddleware())
-	admin.Use(middleware.AuthRateLimitMiddleware(authRepo, 200, time.Hour))
-
-	admin.GET("/users", userController.GetUsers)
-	admin.GET("/users/:id", userController.GetUser)
-	admin.PUT("/users/:id", userController.UpdateUser)
-	admin.DELETE("/users/:id", userController.DeleteUser)
-	admin.PATCH("/users/:id/toggle-status", userController.ToggleUserStatus)
+	admin.Use(middleware.AdminMiddleware(roleService))
+	admin.Use(middleware.AuditMiddleware())
+	admin.Use(middleware.RateLimit(requestLimiter, config.RateLimitAdmin))
+
+	// Permission checks for admin endpoints
+	canReadUsers := middleware.RequirePermission(roleService, models.PermissionUsersRead)
+	canWriteUsers := middleware.RequirePermission(roleService, models.PermissionUsersWrite)
+	canManageWallet := middleware.RequirePermission(roleService, models.PermissionWalletManage)
+	canReadConfig := middleware.RequirePermission(roleService, models.PermissionConfigRead)
+	canEditConfig := middleware.RequirePermission(roleService, models.PermissionConfigEdit)
+	canReadGames := middleware.RequirePermission(roleService, models.PermissionGamesRead)
+	canManageGames := middleware.RequirePermission(roleService, models.PermissionGamesManage)
+	canManageRoles := middleware.RequirePermission(roleService, models.PermissionRolesManage)
+	canReadAudit := middleware.RequirePermission(roleService, models.PermissionAuditRead)
+	canManageEmails := middleware.RequirePermission(roleService, models.PermissionEmailsManage)
+
+	admin.GET("/users", userController.GetUsers, canReadUsers)
+	admin.GET("/users/:id", userController.GetUser, canReadUsers)
+	admin.PUT("/users/:id", userController.UpdateUser, canWriteUsers)
+	admin.DELETE("/users/:id", userController.DeleteUser, canWriteUsers)
+	admin.PATCH("/users/:id/toggle-status", userController.ToggleUserStatus, canWriteUsers)
+	admin.PUT("/users/:id/role", roleController.AssignRole, canManageRoles)
+	admin.GET("/users/:id/transactions", userController.GetUserTransactions, canReadUsers)
+
+	// Balance adjustment endpoints
+	admin.POST("/users/:id/balance-adjustments", userController.AdjustBalance, canManageWallet)
+	admin.GET("/balance-adjustments", userController.GetBalanceAdjustments, canManageWallet)
 
 	// Rate limit management endpoints
-	admin.GET("/rate-limit/info", adminController.GetRateLimitInfo)
-	admin.POST("/rate-limit/reset", adminController.ResetRateLimit)
+	admin.GET("/rate-limit/info", adminController.GetRateLimitInfo, canReadUsers)
+	admin.POST("/rate-limit/reset", adminController.ResetRateLimit, canWriteUsers)
 
 	// Admin game management endpoints
-	admin.GET("/games/stats", gameController.GetGameStats)
-	admin.GET("/games/house-wallet", gameController.GetHouseWallet)
-	admin.POST("/games/:id/simulate", gameController.SimulateOtherPlayers)
+	admin.GET("/games/stats", gameController.GetGameStats, canReadGames)
+	admin.GET("/analytics", analyticsController.GetOperatorAnalytics, canReadGames)
+	admin.GET("/games/house-wallet", gameController.GetHouseWallet, canManageWallet)
+	admin.POST("/games/:id/simulate", gameController.SimulateOtherPlayers, canManageGames)
+
+	// Admin referral program endpoints
+	admin.GET("/referral-programs", referralController.ListPrograms, canReadConfig)
+	admin.GET("/referral-programs/active", referralController.GetActiveProgram, canReadConfig)
+	admin.POST("/referral-programs", referralController.PublishProgram, canEditConfig)
+	admin.POST("/referral-programs/:version/activate", referralController.ActivateProgram, canEditConfig)
+
+	// Role management endpoints
+	admin.GET("/permissions", roleController.ListPermissions, canManageRoles)
+	admin.GET("/roles", roleController.ListRoles, canManageRoles)
+	admin.POST("/roles", roleController.CreateRole, canManageRoles)
+	admin.PUT("/roles/:name", roleController.UpdateRole, canManageRoles)
+	admin.DELETE("/roles/:name", roleController.DeleteRole, canManageRoles)
+
+	// Audit log endpoints
+	admin.GET("/audit-logs", auditController.SearchLogs, canReadAudit)
+
+	// Streaming CSV/NDJSON exports
+	admin.GET("/exports/users", exportController.ExportUsers, canReadUsers)
+	admin.GET("/exports/bets", exportController.ExportBets, canReadGames)
+	admin.GET("/exports/game-results", exportController.ExportGameResults, canReadGames)
+	admin.GET("/exports/commissions", exportController.ExportCommissions, canManageWallet)
+
+	// Email outbox endpoints
+	admin.GET("/emails", outboxController.ListEmails, canManageEmails)
+	admin.GET("/emails/:id", outboxController.GetEmail, canManageEmails)
+	admin.POST("/emails/:id/retry", outboxController.RetryEmail, canManageEmails)
 }
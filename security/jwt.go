@@ -1,6 +1,8 @@
 package security
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"time"
 
@@ -22,24 +24,41 @@ type Claims struct {
 	Email    string `json:"email"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	FamilyID string `json:"fid,omitempty"` // refresh token family the access token was issued from
+	// Issue time in Unix milliseconds. A login can follow a revoke-all within the same second,
+	// which the standard iat claim cannot tell apart.
+	IssuedAtMillis int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT token for a user
-func GenerateToken(userID, email, username, role string) (string, error) {
+// AccessTokenTTL returns how long access tokens are valid
+func AccessTokenTTL() time.Duration {
+	return config.GetConfig().JWT.AccessTokenTTL
+}
+
+// RefreshTokenTTL returns how long refresh tokens are valid
+func RefreshTokenTTL() time.Duration {
+	return config.GetConfig().JWT.RefreshTokenTTL
+}
+
+// GenerateToken generates a short-lived JWT access token for a user
+func GenerateToken(userID, email, username, role, familyID string) (string, error) {
 	if len(jwtSecret) == 0 {
 		InitJWT()
 	}
 
+	now := time.Now()
 	claims := Claims{
-		UserID:   userID,
-		Email:    email,
-		Username: username,
-		Role:     role,
+		UserID:         userID,
+		Email:          email,
+		Username:       username,
+		Role:           role,
+		FamilyID:       familyID,
+		IssuedAtMillis: now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
@@ -71,13 +90,11 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-// RefreshToken generates a new token with extended expiration
-func RefreshToken(tokenString string) (string, error) {
-	claims, err := ValidateToken(tokenString)
-	if err != nil {
+// GenerateRefreshToken generates an opaque random refresh token
+func GenerateRefreshToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
-
-	// Generate new token with extended expiration
-	return GenerateToken(claims.UserID, claims.Email, claims.Username, claims.Role)
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
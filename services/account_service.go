@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/security"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChangePassword changes a user's password after checking the current one. Every other
+// session is signed out; the session making the change stays logged in.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !security.CheckPasswordHash(currentPassword, user.Password) {
+		return errors.New("current password is incorrect")
+	}
+	if currentPassword == newPassword {
+		return errors.New("new password must be different from the current password")
+	}
+
+	hashedPassword, err := security.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	updateData := map[string]interface{}{
+		"password":   hashedPassword,
+		"updated_at": time.Now(),
+	}
+
+	if err := s.userRepo.Update(ctx, user.ID, updateData); err != nil {
+		return errors.New("failed to update password")
+	}
+
+	return s.sessionService.RevokeOtherSessions(ctx, userID, sessionID)
+}
+
+// RequestEmailChange starts an email change: the new address gets an OTP and the current
+// address gets a notice. The email is only swapped once the OTP is confirmed.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID, newEmail, password string) error {
+	newEmail = utils.SanitizeEmail(newEmail)
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !security.CheckPasswordHash(password, user.Password) {
+		return errors.New("invalid password")
+	}
+	if newEmail == user.Email {
+		return errors.New("new email must be different from the current email")
+	}
+
+	if existing, _ := s.userRepo.GetByEmail(ctx, newEmail); existing != nil {
+		return errors.New("email already in use")
+	}
+
+	if err := s.userRepo.Update(ctx, user.ID, map[string]interface{}{"pending_email": newEmail}); err != nil {
+		return err
+	}
+
+	if err := s.otpService.GenerateAndSendOTP(ctx, newEmail, user.Username, user.Language, OTPTypeEmailChange); err != nil {
+		return fmt.Errorf("failed to send verification code: %w", err)
+	}
+
+	// Let the owner of the current address know, in case the account is compromised
+	if err := s.otpService.emailService.SendEmailChangeNotice(user.Email, user.Username, user.Language, newEmail); err != nil {
+		fmt.Printf("Warning: failed to send email change notice: %v\n", err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange verifies the OTP sent to the pending address and makes it the account email
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, userID, otp string) (string, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", errors.New("invalid user ID")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+
+	if user.PendingEmail == "" {
+		return "", errors.New("no email change in progress")
+	}
+
+	if err := s.otpService.VerifyOTP(ctx, user.PendingEmail, otp, OTPTypeEmailChange); err != nil {
+		return "", err
+	}
+
+	// The address may have been taken while the code was outstanding
+	if existing, _ := s.userRepo.GetByEmail(ctx, user.PendingEmail); existing != nil {
+		return "", errors.New("email already in use")
+	}
+
+	updateData := map[string]interface{}{
+		"email":             user.PendingEmail,
+		"pending_email":     "",
+		"is_email_verified": true,
+		"updated_at":        time.Now(),
+	}
+
+	if err := s.userRepo.Update(ctx, user.ID, updateData); err != nil {
+		return "", errors.New("failed to update email")
+	}
+
+	return user.PendingEmail, nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+)
+
+// Analytics limits
+const (
+	AnalyticsDefaultRange = 30 * 24 * time.Hour
+	AnalyticsMaxBuckets   = 1000
+)
+
+type AnalyticsService struct {
+	analyticsRepo *repositories.AnalyticsRepository
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(analyticsRepo *repositories.AnalyticsRepository) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo: analyticsRepo,
+	}
+}
+
+// GetOperatorAnalytics gets the operator metrics between from and to, bucketed by granularity.
+// The range defaults to the last 30 days by day and starts at the beginning of its first bucket.
+func (s *AnalyticsService) GetOperatorAnalytics(ctx context.Context, from, to *time.Time, granularity string) (*models.OperatorAnalytics, error) {
+	if granularity == "" {
+		granularity = models.AnalyticsGranularityDay
+	}
+	if !isAnalyticsGranularity(granularity) {
+		return nil, errors.New("invalid granularity: must be hour, day, week or month")
+	}
+
+	end := time.Now().UTC()
+	if to != nil {
+		end = to.UTC()
+	}
+	start := end.Add(-AnalyticsDefaultRange)
+	if from != nil {
+		start = from.UTC()
+	}
+	if !start.Before(end) {
+		return nil, errors.New("invalid date range: from must be before to")
+	}
+	start = analyticsBucketStart(start, granularity)
+
+	// Lay out every bucket up front so periods without activity are reported as zeros
+	var buckets []models.AnalyticsBucket
+	index := make(map[int64]int)
+	for t := start; t.Before(end); t = nextAnalyticsBucket(t, granularity) {
+		if len(buckets) == AnalyticsMaxBuckets {
+			return nil, fmt.Errorf("invalid date range: more than %d %s buckets, use a coarser granularity", AnalyticsMaxBuckets, granularity)
+		}
+		index[t.Unix()] = len(buckets)
+		buckets = append(buckets, models.AnalyticsBucket{Start: t})
+	}
+
+	results, err := s.analyticsRepo.ResultBuckets(ctx, start, end, granularity)
+	if err != nil {
+		return nil, err
+	}
+	skims, err := s.analyticsRepo.SkimBuckets(ctx, start, end, granularity)
+	if err != nil {
+		return nil, err
+	}
+	commissions, err := s.analyticsRepo.CommissionBuckets(ctx, start, end, granularity)
+	if err != nil {
+		return nil, err
+	}
+	depositors, err := s.analyticsRepo.NewDepositorBuckets(ctx, start, end, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range results {
+		if i, ok := index[row.Start.Unix()]; ok {
+			buckets[i].Bets = row.Bets
+			buckets[i].Wagered = row.Wagered
+			buckets[i].Payouts = row.Payouts
+			buckets[i].ActivePlayers = row.ActivePlayers
+		}
+	}
+	for _, row := range skims {
+		if i, ok := index[row.Start.Unix()]; ok {
+			buckets[i].Rounds = row.Rounds
+			buckets[i].AdminSkim = row.AdminSkim
+		}
+	}
+	for _, row := range commissions {
+		if i, ok := index[row.Start.Unix()]; ok {
+			buckets[i].ReferralCommissions = row.ReferralCommissions
+		}
+	}
+	for _, row := range depositors {
+		if i, ok := index[row.Start.Unix()]; ok {
+			buckets[i].NewDepositors = row.NewDepositors
+		}
+	}
+
+	totals := models.AnalyticsBucket{Start: start}
+	for i := range buckets {
+		deriveAnalyticsMetrics(&buckets[i])
+
+		totals.Rounds += buckets[i].Rounds
+		totals.Bets += buckets[i].Bets
+		totals.Wagered += buckets[i].Wagered
+		totals.Payouts += buckets[i].Payouts
+		totals.AdminSkim += buckets[i].AdminSkim
+		totals.ReferralCommissions += buckets[i].ReferralCommissions
+		totals.NewDepositors += buckets[i].NewDepositors
+	}
+
+	// Players active in several buckets are only counted once over the whole range
+	totals.ActivePlayers, err = s.analyticsRepo.CountActivePlayers(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	deriveAnalyticsMetrics(&totals)
+
+	balls, err := s.analyticsRepo.BallDistribution(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	baskets, err := s.analyticsRepo.BasketDistribution(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	availableBaskets := models.GetAvailableBaskets()
+	for i := range baskets {
+		if baskets[i].BasketID >= 0 && baskets[i].BasketID < len(availableBaskets) {
+			baskets[i].Multiplier = availableBaskets[baskets[i].BasketID].Value
+		}
+	}
+
+	if balls == nil {
+		balls = []models.BallDistribution{}
+	}
+	if baskets == nil {
+		baskets = []models.BasketDistribution{}
+	}
+
+	return &models.OperatorAnalytics{
+		From:        start,
+		To:          end,
+		Granularity: granularity,
+		Totals:      totals,
+		Buckets:     buckets,
+		Balls:       balls,
+		Baskets:     baskets,
+	}, nil
+}
+
+// deriveAnalyticsMetrics fills in the metrics computed from the aggregated sums
+func deriveAnalyticsMetrics(bucket *models.AnalyticsBucket) {
+	bucket.GGR = bucket.Wagered - bucket.Payouts
+	bucket.NetRevenue = bucket.GGR - bucket.AdminSkim - bucket.ReferralCommissions
+	if bucket.Bets > 0 {
+		bucket.AverageBet = bucket.Wagered / float64(bucket.Bets)
+	}
+}
+
+// isAnalyticsGranularity checks if a granularity is supported
+func isAnalyticsGranularity(granularity string) bool {
+	switch granularity {
+	case models.AnalyticsGranularityHour, models.AnalyticsGranularityDay,
+		models.AnalyticsGranularityWeek, models.AnalyticsGranularityMonth:
+		return true
+	}
+	return false
+}
+
+// analyticsBucketStart truncates a UTC time the same way the aggregation pipelines do
+func analyticsBucketStart(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.AnalyticsGranularityHour:
+		return t.Truncate(time.Hour)
+	case models.AnalyticsGranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case models.AnalyticsGranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextAnalyticsBucket gets the start of the bucket following the one starting at t
+func nextAnalyticsBucket(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.AnalyticsGranularityHour:
+		return t.Add(time.Hour)
+	case models.AnalyticsGranularityWeek:
+		return t.AddDate(0, 0, 7)
+	case models.AnalyticsGranularityMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
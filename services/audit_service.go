@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+)
+
+type auditActorKey struct{}
+
+// WithAuditActor attaches the actor performing a request to its context
+func WithAuditActor(ctx context.Context, actor *models.AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFromContext gets the actor attached to a context, if any
+func AuditActorFromContext(ctx context.Context) *models.AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(*models.AuditActor)
+	return actor
+}
+
+type AuditService struct {
+	auditRepo *repositories.AuditRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditRepo *repositories.AuditRepository) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record appends an audit entry for an action performed by the actor in ctx. Only fields
+// whose values differ between before and after are kept.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string, before, after map[string]interface{}) {
+	entry := &models.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if actor := AuditActorFromContext(ctx); actor != nil {
+		entry.Actor = *actor
+	}
+	entry.Before, entry.After = diffAuditFields(before, after)
+
+	// The action already happened, so a failed write is reported rather than returned
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		fmt.Printf("Warning: Failed to write audit log for %s on %s %s: %v\n", action, targetType, targetID, err)
+	}
+}
+
+// SearchLogs searches audit log entries with pagination
+func (s *AuditService) SearchLogs(ctx context.Context, filter *models.AuditLogFilter, page, limit int64) ([]models.AuditLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	skip := (page - 1) * limit
+
+	entries, err := s.auditRepo.Search(ctx, filter, skip, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.auditRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// diffAuditFields keeps only the fields in after whose values differ from before
+func diffAuditFields(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	if before == nil || after == nil {
+		return before, after
+	}
+
+	changedBefore := make(map[string]interface{})
+	changedAfter := make(map[string]interface{})
+	for key, newValue := range after {
+		oldValue, ok := before[key]
+		if ok && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changedBefore[key] = oldValue
+		changedAfter[key] = newValue
+	}
+	return changedBefore, changedAfter
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/HSouheil/bucketball_backend/repositories"
 	"github.com/HSouheil/bucketball_backend/security"
+	"github.com/HSouheil/bucketball_backend/templates"
 	"github.com/HSouheil/bucketball_backend/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,16 +21,22 @@ type AuthService struct {
 	rateLimitSvc   *RateLimitService
 	otpService     *OTPService
 	referralService *ReferralService
+	sessionService  *SessionService
+	roleService     *RoleService
+	auditService    *AuditService
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(userRepo *repositories.UserRepository, authRepo *repositories.AuthRepository, otpService *OTPService) *AuthService {
+func NewAuthService(userRepo *repositories.UserRepository, authRepo *repositories.AuthRepository, otpService *OTPService, referralService *ReferralService, sessionService *SessionService, roleService *RoleService, auditService *AuditService) *AuthService {
 	return &AuthService{
 		userRepo:        userRepo,
 		authRepo:        authRepo,
 		rateLimitSvc:    NewRateLimitService(authRepo),
 		otpService:      otpService,
-		referralService: NewReferralService(userRepo),
+		referralService: referralService,
+		sessionService:  sessionService,
+		roleService:     roleService,
+		auditService:    auditService,
 	}
 }
 
@@ -110,6 +117,7 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 		ReferralCode:     userReferralCode,
 		ReferredBy:       referredBy,
 		ReferralEarnings: 0.0,
+		Language:         templates.NormalizeLocale(req.Language),
 		CreatedAt:        time.Now(),
 		UpdatedAt:        time.Now(),
 	}
@@ -118,11 +126,10 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 		return nil, "", err
 	}
 
-	// Generate and send OTP for email verification
-	if err := s.otpService.GenerateAndSendOTP(ctx, user.Email, user.Username, "registration"); err != nil {
-		// Log the error but don't fail the registration
-		fmt.Printf("Warning: failed to send OTP email: %v\n", err)
-		// Note: In production, you might want to return this error or handle it differently
+	// Generate the OTP and queue the verification email; the outbox worker retries delivery
+	if err := s.otpService.GenerateAndSendOTP(ctx, user.Email, user.Username, user.Language, OTPTypeRegistration); err != nil {
+		// The account exists already, so the user can still request a new code via resend-otp
+		fmt.Printf("Warning: failed to queue OTP email: %v\n", err)
 	}
 
 	// Don't generate token yet - user needs to verify email first
@@ -130,19 +137,22 @@ func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest)
 	return user, "", nil
 }
 
-// Login authenticates a user with rate limiting
-func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, clientIP string) (*models.User, string, error) {
+// Login authenticates a user with rate limiting. Accounts that require two-factor
+// authentication get a challenge instead of tokens.
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, client *models.ClientInfo) (*models.User, *models.TokenPair, *models.TwoFactorChallenge, error) {
+	clientIP := client.IP
+
 	// Sanitize email input
 	req.Email = utils.SanitizeEmail(req.Email)
 
 	// Check rate limit before attempting login
 	allowed, timeLeft, err := s.rateLimitSvc.CheckLoginRateLimit(ctx, req.Email, clientIP)
 	if err != nil {
-		return nil, "", errors.New("rate limit check failed")
+		return nil, nil, nil, errors.New("rate limit check failed")
 	}
 
 	if !allowed {
-		return nil, "", fmt.Errorf("too many login attempts. Please try again in %v", timeLeft.Round(time.Minute))
+		return nil, nil, nil, fmt.Errorf("too many login attempts. Please try again in %v", timeLeft.Round(time.Minute))
 	}
 
 	// Get user by email
@@ -151,52 +161,66 @@ func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, clien
 		if err == mongo.ErrNoDocuments {
 			// Record failed attempt
 			s.rateLimitSvc.RecordLoginAttempt(ctx, req.Email, clientIP, false)
-			return nil, "", errors.New("invalid email or password")
+			return nil, nil, nil, errors.New("invalid email or password")
 		}
-		return nil, "", err
+		return nil, nil, nil, err
 	}
 
 	// Check if user is active
 	if !user.IsActive {
 		// Record failed attempt
 		s.rateLimitSvc.RecordLoginAttempt(ctx, req.Email, clientIP, false)
-		return nil, "", errors.New("account is deactivated")
+		return nil, nil, nil, errors.New("account is deactivated")
 	}
 
 	// Check if email is verified
 	if !user.IsEmailVerified {
 		// Record failed attempt
 		s.rateLimitSvc.RecordLoginAttempt(ctx, req.Email, clientIP, false)
-		return nil, "", errors.New("email not verified. Please check your email for the OTP code")
+		return nil, nil, nil, errors.New("email not verified. Please check your email for the OTP code")
 	}
 
 	// Check password
 	if !security.CheckPasswordHash(req.Password, user.Password) {
 		// Record failed attempt
 		s.rateLimitSvc.RecordLoginAttempt(ctx, req.Email, clientIP, false)
-		return nil, "", errors.New("invalid email or password")
+		return nil, nil, nil, errors.New("invalid email or password")
 	}
 
 	// Record successful attempt (clears rate limit counters)
 	s.rateLimitSvc.RecordLoginAttempt(ctx, req.Email, clientIP, true)
 
-	// Generate token
-	token, err := security.GenerateToken(user.ID.Hex(), user.Email, user.Username, user.Role)
+	// Password is correct, but the second factor is still outstanding
+	requiresTwoFactor, err := s.requiresTwoFactor(ctx, user)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, nil, err
+	}
+	if requiresTwoFactor {
+		challenge, err := s.createTwoFactorChallenge(ctx, user)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return user, nil, challenge, nil
 	}
 
-	// Store token in Redis
-	if err := s.authRepo.SetToken(ctx, token, user.ID.Hex(), 24*time.Hour); err != nil {
-		return nil, "", err
+	// Start a new session with a fresh token pair
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	return user, token, nil
+	return user, tokens, nil, nil
 }
 
-// Logout logs out a user
+// Logout logs out a user and revokes the session the token belongs to
 func (s *AuthService) Logout(ctx context.Context, token string) error {
-	return s.authRepo.SetBlacklistToken(ctx, token, 24*time.Hour)
+	if claims, err := security.ValidateToken(token); err == nil && claims.FamilyID != "" {
+		if err := s.authRepo.DeleteSession(ctx, claims.UserID, claims.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	return s.authRepo.SetBlacklistToken(ctx, token, security.AccessTokenTTL())
 }
 
 // GetUserByID gets a user by ID
@@ -210,7 +234,7 @@ func (s *AuthService) GetUserByID(ctx context.Context, userID string) (*models.U
 }
 
 // UpdateUser updates user profile
-func (s *AuthService) UpdateUser(ctx context.Context, userID string, req *models.UpdateUserRequest) error {
+func (s *AuthService) UpdateUser(ctx context.Context, userID string, req *models.UpdateProfileRequest) error {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.New("invalid user ID")
@@ -253,11 +277,8 @@ func (s *AuthService) UpdateUser(ctx context.Context, userID string, req *models
 	if req.Location != nil {
 		updateData["location"] = *req.Location
 	}
-	if req.Balance != nil {
-		updateData["balance"] = *req.Balance
-	}
-	if req.Withdraw != nil {
-		updateData["withdraw"] = *req.Withdraw
+	if req.Language != nil {
+		updateData["language"] = *req.Language
 	}
 
 	if len(updateData) == 0 {
@@ -276,7 +297,24 @@ func (s *AuthService) GetRateLimitInfo(ctx context.Context, email, ip string) (m
 
 // ResetRateLimit resets rate limits for an email/IP
 func (s *AuthService) ResetRateLimit(ctx context.Context, email, ip string) error {
-	return s.rateLimitSvc.ResetLoginAttempts(ctx, email, ip)
+	before, err := s.rateLimitSvc.GetLoginAttemptsInfo(ctx, email, ip)
+	if err != nil {
+		before = nil
+	}
+
+	if err := s.rateLimitSvc.ResetLoginAttempts(ctx, email, ip); err != nil {
+		return err
+	}
+
+	target := email
+	if target == "" {
+		target = ip
+	}
+	s.auditService.Record(ctx, models.AuditActionRateLimitReset, models.AuditTargetRateLimit, target, before, map[string]interface{}{
+		"email": email,
+		"ip":    ip,
+	})
+	return nil
 }
 
 // GetReferralStats gets referral statistics for a user
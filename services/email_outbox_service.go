@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Outbox delivery settings
+const (
+	EmailMaxAttempts   = 8
+	EmailRetryBase     = 30 * time.Second // delay after the first failure, doubled after each one
+	EmailRetryMaxDelay = 2 * time.Hour
+	EmailPollInterval  = 5 * time.Second
+	EmailSendTimeout   = time.Minute      // a claimed email is reclaimed if the worker dies mid-send
+	EmailSMTPTimeout   = 40 * time.Second // bounds one SMTP delivery, well within EmailSendTimeout
+)
+
+// EmailOutboxService delivers queued emails and lets admins inspect and retry them
+type EmailOutboxService struct {
+	outboxRepo   *repositories.EmailOutboxRepository
+	emailService *EmailService
+	auditService *AuditService
+}
+
+// NewEmailOutboxService creates a new email outbox service
+func NewEmailOutboxService(outboxRepo *repositories.EmailOutboxRepository, emailService *EmailService, auditService *AuditService) *EmailOutboxService {
+	return &EmailOutboxService{
+		outboxRepo:   outboxRepo,
+		emailService: emailService,
+		auditService: auditService,
+	}
+}
+
+// Run delivers due emails until the context is cancelled
+func (s *EmailOutboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(EmailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain everything that is due before waiting for the next tick
+		for {
+			processed, err := s.processNext(ctx)
+			if err != nil {
+				fmt.Printf("Warning: email outbox worker failed: %v\n", err)
+				break
+			}
+			if !processed {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNext claims and sends one due email, reporting whether there was one
+func (s *EmailOutboxService) processNext(ctx context.Context) (bool, error) {
+	email, err := s.outboxRepo.ClaimNext(ctx, EmailSendTimeout)
+	if err != nil {
+		return false, err
+	}
+	if email == nil {
+		return false, nil
+	}
+
+	if sendErr := s.emailService.deliver(email); sendErr != nil {
+		dead := email.Attempts >= EmailMaxAttempts
+		nextAttemptAt := time.Now().Add(emailRetryDelay(email.Attempts))
+		if err := s.outboxRepo.MarkFailed(ctx, email.ID, sendErr.Error(), nextAttemptAt, dead); err != nil {
+			return true, err
+		}
+		if dead {
+			fmt.Printf("Warning: giving up on email %s to %s after %d attempts: %v\n", email.ID.Hex(), email.To, email.Attempts, sendErr)
+		}
+		return true, nil
+	}
+
+	return true, s.outboxRepo.MarkSent(ctx, email.ID)
+}
+
+// emailRetryDelay returns the exponential backoff delay after the given number of attempts
+func emailRetryDelay(attempts int) time.Duration {
+	delay := EmailRetryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= EmailRetryMaxDelay {
+			return EmailRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// ListEmails gets outbox emails, optionally filtered by status
+func (s *EmailOutboxService) ListEmails(ctx context.Context, status string, page, limit int64) ([]models.OutboxEmail, int64, error) {
+	switch status {
+	case "", models.EmailStatusPending, models.EmailStatusSending, models.EmailStatusSent, models.EmailStatusDead:
+	default:
+		return nil, 0, errors.New("invalid status")
+	}
+
+	skip, limit := pageBounds(page, limit)
+
+	emails, err := s.outboxRepo.List(ctx, status, skip, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.outboxRepo.Count(ctx, status)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return emails, total, nil
+}
+
+// GetEmail gets an outbox email by ID
+func (s *EmailOutboxService) GetEmail(ctx context.Context, emailID string) (*models.OutboxEmail, error) {
+	objectID, err := primitive.ObjectIDFromHex(emailID)
+	if err != nil {
+		return nil, errors.New("invalid email ID")
+	}
+
+	email, err := s.outboxRepo.GetByID(ctx, objectID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("email not found")
+		}
+		return nil, err
+	}
+	return email, nil
+}
+
+// RetryEmail requeues a dead-lettered email with a fresh set of attempts
+func (s *EmailOutboxService) RetryEmail(ctx context.Context, emailID string) error {
+	email, err := s.GetEmail(ctx, emailID)
+	if err != nil {
+		return err
+	}
+	if email.Status != models.EmailStatusDead {
+		return errors.New("only failed emails can be retried")
+	}
+
+	if err := s.outboxRepo.Retry(ctx, email.ID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("only failed emails can be retried")
+		}
+		return err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionEmailRetry, models.AuditTargetEmail, email.ID.Hex(), map[string]interface{}{
+		"status":     email.Status,
+		"attempts":   email.Attempts,
+		"last_error": email.LastError,
+	}, map[string]interface{}{
+		"status":     models.EmailStatusPending,
+		"attempts":   0,
+		"last_error": email.LastError,
+	})
+	return nil
+}
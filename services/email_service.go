@@ -1,110 +1,213 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"net/smtp"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
 
 	"github.com/HSouheil/bucketball_backend/config"
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/HSouheil/bucketball_backend/templates"
+	"github.com/HSouheil/bucketball_backend/utils"
 )
 
-// EmailService handles email sending
+// EmailService renders emails into the outbox and delivers them through the configured transport
 type EmailService struct {
-	config *config.EmailConfig
+	config     *config.EmailConfig
+	outboxRepo *repositories.EmailOutboxRepository
+	transport  MailTransport
 }
 
 // NewEmailService creates a new email service
-func NewEmailService(cfg *config.EmailConfig) *EmailService {
+func NewEmailService(cfg *config.EmailConfig, outboxRepo *repositories.EmailOutboxRepository, transport MailTransport) *EmailService {
 	return &EmailService{
-		config: cfg,
+		config:     cfg,
+		outboxRepo: outboxRepo,
+		transport:  transport,
 	}
 }
 
 // SendOTPEmail sends an OTP verification email
-func (s *EmailService) SendOTPEmail(toEmail, username, otp string) error {
-	subject := "Verify Your Email - BucketBall"
-	body := s.buildOTPEmailBody(username, otp)
+func (s *EmailService) SendOTPEmail(toEmail, username, locale, otp string) error {
+	return s.sendTemplate(toEmail, locale, templates.EmailOTP, map[string]interface{}{
+		"Username":      username,
+		"Code":          otp,
+		"ExpiryMinutes": OTPExpiryMinutes,
+	})
+}
 
-	return s.sendEmail(toEmail, subject, body)
+// SendPasswordResetEmail sends a password reset OTP email
+func (s *EmailService) SendPasswordResetEmail(toEmail, username, locale, otp string) error {
+	return s.sendTemplate(toEmail, locale, templates.EmailPasswordReset, map[string]interface{}{
+		"Username":      username,
+		"Code":          otp,
+		"ExpiryMinutes": OTPExpiryMinutes,
+	})
 }
 
-// buildOTPEmailBody builds the HTML body for OTP email
-func (s *EmailService) buildOTPEmailBody(username, otp string) string {
-	return fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Email Verification</title>
-</head>
-<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
-    <div style="background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); padding: 30px; text-align: center; border-radius: 10px 10px 0 0;">
-        <h1 style="color: white; margin: 0;">BucketBall</h1>
-    </div>
-    
-    <div style="background-color: #f9f9f9; padding: 30px; border-radius: 0 0 10px 10px; border: 1px solid #ddd;">
-        <h2 style="color: #667eea; margin-top: 0;">Welcome, %s!</h2>
-        
-        <p>Thank you for registering with BucketBall. To complete your registration, please verify your email address using the OTP code below:</p>
-        
-        <div style="background-color: white; border: 2px dashed #667eea; border-radius: 8px; padding: 20px; text-align: center; margin: 30px 0;">
-            <h1 style="color: #667eea; font-size: 42px; letter-spacing: 10px; margin: 0;">%s</h1>
-        </div>
-        
-        <p style="color: #666; font-size: 14px; margin-top: 20px;">This OTP code will expire in <strong>10 minutes</strong>.</p>
-        
-        <p style="color: #666; font-size: 14px;">If you didn't request this verification, please ignore this email.</p>
-        
-        <hr style="border: none; border-top: 1px solid #ddd; margin: 30px 0;">
-        
-        <p style="color: #999; font-size: 12px; text-align: center;">
-            This is an automated email. Please do not reply.<br>
-            © 2024 BucketBall. All rights reserved.
-        </p>
-    </div>
-</body>
-</html>
-`, username, otp)
+// SendEmailChangeEmail sends the OTP that confirms a new email address
+func (s *EmailService) SendEmailChangeEmail(toEmail, username, locale, otp string) error {
+	return s.sendTemplate(toEmail, locale, templates.EmailChange, map[string]interface{}{
+		"Username":      username,
+		"Code":          otp,
+		"ExpiryMinutes": OTPExpiryMinutes,
+	})
 }
 
-// sendEmail sends an email using SMTP
-func (s *EmailService) sendEmail(to, subject, body string) error {
-	from := s.config.FromEmail
+// SendEmailChangeNotice warns the current address that a change to another address was requested
+func (s *EmailService) SendEmailChangeNotice(toEmail, username, locale, newEmail string) error {
+	return s.sendTemplate(toEmail, locale, templates.EmailChangeNotice, map[string]interface{}{
+		"Username": username,
+		"NewEmail": utils.MaskEmail(newEmail),
+	})
+}
+
+// SendWithdrawalStatusEmail tells a user their withdrawal changed status (pending, approved, completed, rejected)
+func (s *EmailService) SendWithdrawalStatusEmail(toEmail, username, locale, status string, amount, balance float64, reason string) error {
+	return s.sendTemplate(toEmail, locale, templates.EmailWithdrawalStatus, map[string]interface{}{
+		"Username": username,
+		"Status":   status,
+		"Amount":   amount,
+		"Balance":  balance,
+		"Reason":   reason,
+	})
+}
 
-	// Set up authentication
-	auth := smtp.PlainAuth("",
-		s.config.SMTPUsername,
-		s.config.SMTPPassword,
-		s.config.SMTPHost,
-	)
+// SendBigWinEmail congratulates a user on a large win
+func (s *EmailService) SendBigWinEmail(toEmail, username, locale string, amount, multiplier float64) error {
+	return s.sendTemplate(toEmail, locale, templates.EmailBigWin, map[string]interface{}{
+		"Username":   username,
+		"Amount":     amount,
+		"Multiplier": multiplier,
+	})
+}
 
-	// Build email message
-	message := s.buildEmailMessage(from, to, subject, body)
+// SendNotificationEmail sends a copy of an in-app notification
+func (s *EmailService) SendNotificationEmail(toEmail, username, locale, title, message string) error {
+	return s.sendTemplate(toEmail, locale, templates.EmailNotification, map[string]interface{}{
+		"Username": username,
+		"Title":    title,
+		"Message":  message,
+	})
+}
 
-	// Send email
-	addr := fmt.Sprintf("%s:%s", s.config.SMTPHost, s.config.SMTPPort)
-	err := smtp.SendMail(addr, auth, from, []string{to}, []byte(message))
+// sendTemplate renders an email template in the recipient's locale and queues it in the outbox.
+// Delivery happens in the background so a slow or unavailable SMTP server never blocks a request.
+func (s *EmailService) sendTemplate(to, locale, name string, data map[string]interface{}) error {
+	email, err := templates.RenderEmail(name, locale, data)
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.outboxRepo.Enqueue(ctx, &models.OutboxEmail{
+		To:       to,
+		Template: name,
+		Locale:   templates.NormalizeLocale(locale),
+		Subject:  email.Subject,
+		HTML:     email.HTML,
+		Text:     email.Text,
+	}); err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
 	}
 
 	return nil
 }
 
-// buildEmailMessage builds the email message with headers
-func (s *EmailService) buildEmailMessage(from, to, subject, body string) string {
-	headers := make(map[string]string)
-	headers["From"] = fmt.Sprintf("%s <%s>", s.config.FromName, from)
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
-
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+// deliver sends a queued email
+func (s *EmailService) deliver(email *models.OutboxEmail) error {
+	return s.sendEmail(email.To, &templates.Email{
+		Subject: email.Subject,
+		HTML:    email.HTML,
+		Text:    email.Text,
+	})
+}
+
+// sendEmail builds a message and hands it to the mail transport
+func (s *EmailService) sendEmail(to string, email *templates.Email) error {
+	from := s.config.FromEmail
+
+	message, err := s.buildEmailMessage(from, to, email)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	return s.transport.Send(from, []string{to}, message)
+}
+
+// buildEmailMessage builds a multipart/alternative message with plain-text and HTML parts.
+// Non-ASCII header values are RFC 2047 encoded and bodies are quoted-printable.
+func (s *EmailService) buildEmailMessage(from, to string, email *templates.Email) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", email.Text},
+		{"text/html; charset=UTF-8", email.HTML},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
 	}
-	message += "\r\n" + body
 
-	return message
+	domain := from[strings.LastIndex(from, "@")+1:]
+
+	// Headers are written in a fixed order
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", (&mail.Address{Name: s.config.FromName, Address: from}).String()},
+		{"To", (&mail.Address{Address: to}).String()},
+		{"Subject", mime.QEncoding.Encode("UTF-8", email.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", newMessageID(), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary())},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", h[0], h[1])
+	}
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+// newMessageID generates the random local part of a Message-ID header
+func newMessageID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(bytes)
 }
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportFlushEvery is how many rows are written between flushes to the client
+const ExportFlushEvery = 500
+
+// ExportFunc streams an export to a writer
+type ExportFunc func(ctx context.Context, w io.Writer) error
+
+// exportColumn is one selectable column of an export
+type exportColumn[T any] struct {
+	Name  string
+	Value func(T) interface{}
+}
+
+// ExportService streams admin exports of users, bets, game results and commissions
+type ExportService struct {
+	userRepo     *repositories.UserRepository
+	gameRepo     *repositories.GameRepository
+	referralRepo *repositories.ReferralRepository
+	auditService *AuditService
+}
+
+// NewExportService creates a new export service
+func NewExportService(userRepo *repositories.UserRepository, gameRepo *repositories.GameRepository, referralRepo *repositories.ReferralRepository, auditService *AuditService) *ExportService {
+	return &ExportService{
+		userRepo:     userRepo,
+		gameRepo:     gameRepo,
+		referralRepo: referralRepo,
+		auditService: auditService,
+	}
+}
+
+var userExportColumns = []exportColumn[*models.User]{
+	{"id", func(u *models.User) interface{} { return u.ID }},
+	{"email", func(u *models.User) interface{} { return u.Email }},
+	{"username", func(u *models.User) interface{} { return u.Username }},
+	{"first_name", func(u *models.User) interface{} { return u.FirstName }},
+	{"last_name", func(u *models.User) interface{} { return u.LastName }},
+	{"phone_number", func(u *models.User) interface{} { return u.PhoneNumber }},
+	{"country", func(u *models.User) interface{} { return u.Location.Country }},
+	{"role", func(u *models.User) interface{} { return u.Role }},
+	{"is_active", func(u *models.User) interface{} { return u.IsActive }},
+	{"is_email_verified", func(u *models.User) interface{} { return u.IsEmailVerified }},
+	{"balance", func(u *models.User) interface{} { return u.Balance }},
+	{"withdraw", func(u *models.User) interface{} { return u.Withdraw }},
+	{"referral_code", func(u *models.User) interface{} { return u.ReferralCode }},
+	{"referred_by", func(u *models.User) interface{} { return u.ReferredBy }},
+	{"referral_earnings", func(u *models.User) interface{} { return u.ReferralEarnings }},
+	{"created_at", func(u *models.User) interface{} { return u.CreatedAt }},
+	{"deleted_at", func(u *models.User) interface{} { return u.DeletedAt }},
+}
+
+var betExportColumns = []exportColumn[*models.Bet]{
+	{"id", func(b *models.Bet) interface{} { return b.ID }},
+	{"user_id", func(b *models.Bet) interface{} { return b.UserID }},
+	{"game_id", func(b *models.Bet) interface{} { return b.GameID }},
+	{"ball_id", func(b *models.Bet) interface{} { return b.BallID }},
+	{"amount", func(b *models.Bet) interface{} { return b.Amount }},
+	{"status", func(b *models.Bet) interface{} { return b.Status }},
+	{"created_at", func(b *models.Bet) interface{} { return b.CreatedAt }},
+}
+
+var gameResultExportColumns = []exportColumn[*models.GameResult]{
+	{"id", func(r *models.GameResult) interface{} { return r.ID }},
+	{"user_id", func(r *models.GameResult) interface{} { return r.UserID }},
+	{"game_id", func(r *models.GameResult) interface{} { return r.GameID }},
+	{"ball_id", func(r *models.GameResult) interface{} { return r.BallID }},
+	{"ball_name", func(r *models.GameResult) interface{} { return r.BallName }},
+	{"bet_amount", func(r *models.GameResult) interface{} { return r.BetAmount }},
+	{"multiplier", func(r *models.GameResult) interface{} { return r.Multiplier }},
+	{"win_amount", func(r *models.GameResult) interface{} { return r.WinAmount }},
+	{"profit", func(r *models.GameResult) interface{} { return r.Profit }},
+	{"basket_landed", func(r *models.GameResult) interface{} { return r.BasketLanded }},
+	{"won", func(r *models.GameResult) interface{} { return r.Won }},
+	{"pushed", func(r *models.GameResult) interface{} { return r.Pushed }},
+	{"wallet_limited", func(r *models.GameResult) interface{} { return r.WalletLimited }},
+	{"created_at", func(r *models.GameResult) interface{} { return r.CreatedAt }},
+}
+
+var commissionExportColumns = []exportColumn[*models.ReferralCommission]{
+	{"id", func(c *models.ReferralCommission) interface{} { return c.ID }},
+	{"referrer_id", func(c *models.ReferralCommission) interface{} { return c.ReferrerID }},
+	{"referred_user_id", func(c *models.ReferralCommission) interface{} { return c.ReferredUserID }},
+	{"game_id", func(c *models.ReferralCommission) interface{} { return c.GameID }},
+	{"level", func(c *models.ReferralCommission) interface{} { return c.Level }},
+	{"source", func(c *models.ReferralCommission) interface{} { return c.Source }},
+	{"program_version", func(c *models.ReferralCommission) interface{} { return c.ProgramVersion }},
+	{"original_amount", func(c *models.ReferralCommission) interface{} { return c.OriginalAmount }},
+	{"commission_rate", func(c *models.ReferralCommission) interface{} { return c.CommissionRate }},
+	{"commission_amount", func(c *models.ReferralCommission) interface{} { return c.CommissionAmount }},
+	{"status", func(c *models.ReferralCommission) interface{} { return c.Status }},
+	{"created_at", func(c *models.ReferralCommission) interface{} { return c.CreatedAt }},
+}
+
+// ExportUsers prepares an export of the users matching a filter
+func (s *ExportService) ExportUsers(filter *models.UserFilter, format string, columns []string) (ExportFunc, error) {
+	if filter.SortBy != "" && !isUserSortField(filter.SortBy) {
+		return nil, fmt.Errorf("invalid sort field: %s", filter.SortBy)
+	}
+
+	selected, err := selectExportColumns(userExportColumns, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return prepareExport(s, "users", format, selected, func(ctx context.Context, fn func(*models.User) error) error {
+		return s.userRepo.Stream(ctx, filter, fn)
+	})
+}
+
+// ExportBets prepares an export of the bets matching a filter
+func (s *ExportService) ExportBets(filter *models.BetFilter, format string, columns []string) (ExportFunc, error) {
+	selected, err := selectExportColumns(betExportColumns, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return prepareExport(s, "bets", format, selected, func(ctx context.Context, fn func(*models.Bet) error) error {
+		return s.gameRepo.StreamBets(ctx, filter, fn)
+	})
+}
+
+// ExportGameResults prepares an export of the game results matching a filter
+func (s *ExportService) ExportGameResults(filter *models.GameResultFilter, format string, columns []string) (ExportFunc, error) {
+	selected, err := selectExportColumns(gameResultExportColumns, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return prepareExport(s, "game_results", format, selected, func(ctx context.Context, fn func(*models.GameResult) error) error {
+		return s.gameRepo.StreamGameResults(ctx, filter, fn)
+	})
+}
+
+// ExportCommissions prepares an export of the referral commissions matching a filter
+func (s *ExportService) ExportCommissions(filter *models.CommissionFilter, format string, columns []string) (ExportFunc, error) {
+	selected, err := selectExportColumns(commissionExportColumns, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return prepareExport(s, "commissions", format, selected, func(ctx context.Context, fn func(*models.ReferralCommission) error) error {
+		return s.referralRepo.StreamCommissions(ctx, filter, fn)
+	})
+}
+
+// prepareExport checks the format and returns a function that records the export in the
+// audit log and streams every row as it comes off the cursor
+func prepareExport[T any](s *ExportService, dataset, format string, columns []exportColumn[T], stream func(context.Context, func(T) error) error) (ExportFunc, error) {
+	if format != models.ExportFormatCSV && format != models.ExportFormatNDJSON {
+		return nil, errors.New("invalid format: must be csv or ndjson")
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+
+	return func(ctx context.Context, w io.Writer) error {
+		s.auditService.Record(ctx, models.AuditActionDataExport, models.AuditTargetExport, dataset, nil, map[string]interface{}{
+			"format":  format,
+			"columns": names,
+		})
+
+		writer := newExportWriter(w, format, names)
+		if err := writer.WriteHeader(); err != nil {
+			return err
+		}
+
+		rows := 0
+		values := make([]interface{}, len(columns))
+		err := stream(ctx, func(record T) error {
+			for i, column := range columns {
+				values[i] = column.Value(record)
+			}
+			if err := writer.WriteRow(values); err != nil {
+				return err
+			}
+
+			rows++
+			if rows%ExportFlushEvery == 0 {
+				return writer.Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return writer.Flush()
+	}, nil
+}
+
+// selectExportColumns picks the requested columns in the requested order, or every column when none are requested
+func selectExportColumns[T any](all []exportColumn[T], names []string) ([]exportColumn[T], error) {
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	selected := make([]exportColumn[T], 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, column := range all {
+			if column.Name == name {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			available := make([]string, len(all))
+			for i, column := range all {
+				available[i] = column.Name
+			}
+			return nil, fmt.Errorf("invalid column %q (available: %s)", name, strings.Join(available, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// exportWriter writes export rows in one format
+type exportWriter interface {
+	WriteHeader() error
+	WriteRow(values []interface{}) error
+	Flush() error
+}
+
+// newExportWriter creates the writer for an export format
+func newExportWriter(w io.Writer, format string, columns []string) exportWriter {
+	if format == models.ExportFormatNDJSON {
+		return &ndjsonExportWriter{w: w, columns: columns}
+	}
+	return &csvExportWriter{w: w, csv: csv.NewWriter(w), columns: columns}
+}
+
+// csvExportWriter writes rows as CSV with a header line
+type csvExportWriter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	columns []string
+	record  []string
+}
+
+func (cw *csvExportWriter) WriteHeader() error {
+	cw.record = make([]string, len(cw.columns))
+	return cw.csv.Write(cw.columns)
+}
+
+func (cw *csvExportWriter) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		cw.record[i] = csvExportValue(value)
+	}
+	return cw.csv.Write(cw.record)
+}
+
+func (cw *csvExportWriter) Flush() error {
+	cw.csv.Flush()
+	if err := cw.csv.Error(); err != nil {
+		return err
+	}
+	flushResponse(cw.w)
+	return nil
+}
+
+// ndjsonExportWriter writes rows as one JSON object per line, keeping the column order
+type ndjsonExportWriter struct {
+	w       io.Writer
+	columns []string
+	line    []byte
+}
+
+func (nw *ndjsonExportWriter) WriteHeader() error {
+	return nil
+}
+
+func (nw *ndjsonExportWriter) WriteRow(values []interface{}) error {
+	nw.line = append(nw.line[:0], '{')
+	for i, value := range values {
+		if i > 0 {
+			nw.line = append(nw.line, ',')
+		}
+		nw.line = strconv.AppendQuote(nw.line, nw.columns[i])
+		nw.line = append(nw.line, ':')
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		nw.line = append(nw.line, encoded...)
+	}
+	nw.line = append(nw.line, '}', '\n')
+
+	_, err := nw.w.Write(nw.line)
+	return err
+}
+
+func (nw *ndjsonExportWriter) Flush() error {
+	flushResponse(nw.w)
+	return nil
+}
+
+// flushResponse pushes buffered bytes to the client when writing to an HTTP response
+func flushResponse(w io.Writer) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// csvExportValue formats a value for a CSV cell. Text that a spreadsheet would treat as a
+// formula is prefixed with a quote so exported user input cannot run as one.
+func csvExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case primitive.ObjectID:
+		return v.Hex()
+	case *primitive.ObjectID:
+		if v == nil {
+			return ""
+		}
+		return v.Hex()
+	default:
+		return fmt.Sprint(v)
+	}
+}
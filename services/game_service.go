@@ -6,24 +6,37 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/HSouheil/bucketball_backend/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// BigWinMultiplier is the payout multiplier from which a winning bet publishes a big win notification
+const BigWinMultiplier = 4.0
+
 type GameService struct {
-	gameRepo    *repositories.GameRepository
-	userRepo    *repositories.UserRepository
-	houseWallet *models.HouseWallet
+	gameRepo            *repositories.GameRepository
+	userRepo            *repositories.UserRepository
+	referralService     *ReferralService
+	houseWallet         *models.HouseWallet
+	auditService        *AuditService
+	notificationService *NotificationService
+	statsService        *StatsService
 }
 
 // NewGameService creates a new game service
-func NewGameService(gameRepo *repositories.GameRepository, userRepo *repositories.UserRepository) *GameService {
+func NewGameService(gameRepo *repositories.GameRepository, userRepo *repositories.UserRepository, referralService *ReferralService, auditService *AuditService, notificationService *NotificationService, statsService *StatsService) *GameService {
 	return &GameService{
-		gameRepo: gameRepo,
-		userRepo: userRepo,
+		gameRepo:            gameRepo,
+		userRepo:            userRepo,
+		referralService:     referralService,
+		auditService:        auditService,
+		notificationService: notificationService,
+		statsService:        statsService,
 	}
 }
 
@@ -266,12 +279,30 @@ func (s *GameService) PlayGame(ctx context.Context, gameID primitive.ObjectID) e
 	winningBallID := ballIDs[rand.Intn(len(ballIDs))]
 	winningBasketID := ballTargets[winningBallID]
 
+	// Add admin profit (2-4% of total bets)
+	adminProfitRate := 0.02 + rand.Float64()*0.02
+	adminProfit := game.TotalBets * adminProfitRate
+
+	// Describe every ball's drop so all clients animate and replay the same round
+	trajectorySeed, err := models.NewTrajectorySeed()
+	if err != nil {
+		return err
+	}
+	sort.Ints(ballIDs)
+	trajectories := make([]models.BallTrajectory, 0, len(ballIDs))
+	for _, ballID := range ballIDs {
+		trajectories = append(trajectories, models.BuildTrajectory(trajectorySeed, ballID, ballTargets[ballID]))
+	}
+
 	// Update game with results
 	now := time.Now()
 	updateGameData := map[string]interface{}{
 		"status":            "completed",
 		"winning_ball_id":   winningBallID,
 		"winning_basket_id": winningBasketID,
+		"admin_skim":        adminProfit,
+		"trajectory_seed":   trajectorySeed,
+		"trajectories":      trajectories,
 		"completed_at":      now,
 		"updated_at":        now,
 	}
@@ -328,6 +359,7 @@ func (s *GameService) PlayGame(ctx context.Context, gameID primitive.ObjectID) e
 	}
 
 	// Second pass: apply wallet limits and update user balances
+	netByUser := make(map[primitive.ObjectID]float64)
 	for _, result := range tempResults {
 		finalProfit := result.Profit
 		finalWinAmount := result.WinAmount
@@ -354,6 +386,7 @@ func (s *GameService) PlayGame(ctx context.Context, gameID primitive.ObjectID) e
 				"updated_at": time.Now(),
 			}
 			s.userRepo.Update(ctx, result.UserID, updateUserData)
+			netByUser[result.UserID] += finalProfit
 		}
 
 		// Update bet status
@@ -379,6 +412,39 @@ func (s *GameService) PlayGame(ctx context.Context, gameID primitive.ObjectID) e
 		results = append(results, result)
 	}
 
+	// Keep the daily stats rollups current
+	if err := s.statsService.RecordRound(ctx, now, adminProfit, results); err != nil {
+		fmt.Printf("Warning: failed to record round stats: %v\n", err)
+	}
+
+	// Tell every player how the round went for them
+	for userID, net := range netByUser {
+		if err := s.notificationService.Publish(ctx, roundResultNotification(userID, gameID, net)); err != nil {
+			fmt.Printf("Warning: failed to publish round result notification: %v\n", err)
+		}
+	}
+
+	// Call out every winning bet that paid at least BigWinMultiplier
+	for i := range results {
+		if !results[i].Won || results[i].Multiplier < BigWinMultiplier {
+			continue
+		}
+		if err := s.notificationService.Publish(ctx, bigWinNotification(&results[i])); err != nil {
+			fmt.Printf("Warning: failed to publish big win notification: %v\n", err)
+		}
+	}
+
+	// Pay revenue share to referrers of players who lost this round
+	for userID, net := range netByUser {
+		if net >= 0 {
+			continue
+		}
+		if err := s.referralService.ProcessRevenueShare(ctx, userID, gameID, -net); err != nil {
+			// Log error but don't fail the settlement
+			fmt.Printf("Warning: failed to process revenue share: %v\n", err)
+		}
+	}
+
 	// Update house wallet
 	houseWallet, err := s.gameRepo.GetHouseWallet(ctx)
 	if err == nil {
@@ -391,9 +457,6 @@ func (s *GameService) PlayGame(ctx context.Context, gameID primitive.ObjectID) e
 			}
 		}
 
-		// Add admin profit (2-4% of total bets)
-		adminProfitRate := 0.02 + rand.Float64()*0.02
-		adminProfit := game.TotalBets * adminProfitRate
 		netHouseChange -= adminProfit
 
 		updateWalletData := map[string]interface{}{
@@ -407,25 +470,247 @@ func (s *GameService) PlayGame(ctx context.Context, gameID primitive.ObjectID) e
 	return nil
 }
 
-// GetGameHistory gets game history for a user
-func (s *GameService) GetGameHistory(ctx context.Context, userID primitive.ObjectID, limit int64) ([]models.GameResult, error) {
-	return s.gameRepo.GetGameResultsByUserID(ctx, userID, limit)
+// roundResultNotification builds the notification for a player's net result in a round
+func roundResultNotification(userID, gameID primitive.ObjectID, net float64) *models.Notification {
+	notification := &models.Notification{
+		UserID:   userID,
+		Category: models.NotificationCategoryGame,
+		Data: map[string]interface{}{
+			"game_id": gameID.Hex(),
+			"net":     net,
+		},
+	}
+
+	switch {
+	case net > 0:
+		notification.Type = "round_won"
+		notification.Title = fmt.Sprintf("You won $%.2f", net)
+		notification.Message = fmt.Sprintf("Your bets in round %s paid out $%.2f in profit.", gameID.Hex(), net)
+	case net < 0:
+		notification.Type = "round_lost"
+		notification.Title = fmt.Sprintf("You lost $%.2f", -net)
+		notification.Message = fmt.Sprintf("Your bets in round %s lost $%.2f.", gameID.Hex(), -net)
+	default:
+		notification.Type = "round_even"
+		notification.Title = "You broke even"
+		notification.Message = fmt.Sprintf("Your bets in round %s were returned.", gameID.Hex())
+	}
+
+	return notification
 }
 
-// GetGameStats gets overall game statistics
-func (s *GameService) GetGameStats(ctx context.Context) (map[string]interface{}, error) {
-	return s.gameRepo.GetGameStats(ctx)
+// bigWinNotification builds the notification for a winning bet that paid a big multiplier
+func bigWinNotification(result *models.GameResult) *models.Notification {
+	return &models.Notification{
+		UserID:   result.UserID,
+		Category: models.NotificationCategoryGame,
+		Type:     "big_win",
+		Title:    fmt.Sprintf("Big win: $%.2f at %.1fx", result.WinAmount, result.Multiplier),
+		Message:  fmt.Sprintf("Your $%.2f bet on the %s ball in round %s paid out $%.2f.", result.BetAmount, result.BallName, result.GameID.Hex(), result.WinAmount),
+		Data: map[string]interface{}{
+			"game_id":    result.GameID.Hex(),
+			"ball_id":    result.BallID,
+			"amount":     result.WinAmount,
+			"multiplier": result.Multiplier,
+		},
+	}
+}
+
+// GetGameHistory gets a page of a user's game results, newest first, after an optional cursor
+func (s *GameService) GetGameHistory(ctx context.Context, userID primitive.ObjectID, filter *models.GameResultFilter, cursor string, limit int64) (*models.CursorPage[models.GameResult], error) {
+	if filter.Outcome != "" && !models.IsValidOutcome(filter.Outcome) {
+		return nil, errors.New("invalid outcome: must be won, lost or pushed")
+	}
+	if err := validateBallFilter(filter.BallID); err != nil {
+		return nil, err
+	}
+	after, err := decodePageCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.UserID = &userID
+	results, err := s.gameRepo.ListGameResults(ctx, filter, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewCursorPage(results, limit, func(r *models.GameResult) *models.Cursor {
+		return &models.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+	}), nil
+}
+
+// GetBets gets a page of a user's bets, newest first, after an optional cursor
+func (s *GameService) GetBets(ctx context.Context, userID primitive.ObjectID, filter *models.BetFilter, cursor string, limit int64) (*models.CursorPage[models.Bet], error) {
+	if filter.Status != "" && filter.Status != "pending" && !models.IsValidOutcome(filter.Status) {
+		return nil, errors.New("invalid outcome: must be pending, won, lost or pushed")
+	}
+	if err := validateBallFilter(filter.BallID); err != nil {
+		return nil, err
+	}
+	after, err := decodePageCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.UserID = &userID
+	bets, err := s.gameRepo.ListBets(ctx, filter, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewCursorPage(bets, limit, func(b *models.Bet) *models.Cursor {
+		return &models.Cursor{CreatedAt: b.CreatedAt, ID: b.ID}
+	}), nil
+}
+
+// GetRounds gets a page of drawn rounds, newest first, after an optional cursor
+func (s *GameService) GetRounds(ctx context.Context, cursor string, limit int64) (*models.CursorPage[models.RoundSummary], error) {
+	after, err := decodePageCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	games, err := s.gameRepo.ListSettledRounds(ctx, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := models.NewCursorPage(games, limit, func(g *models.Game) *models.Cursor {
+		return &models.Cursor{CreatedAt: g.CreatedAt, ID: g.ID}
+	})
+
+	gameIDs := make([]primitive.ObjectID, len(page.Items))
+	for i, game := range page.Items {
+		gameIDs[i] = game.ID
+	}
+	balls, err := s.gameRepo.AggregateRoundBalls(ctx, gameIDs)
+	if err != nil {
+		return nil, err
+	}
+	ballsByGame := make(map[primitive.ObjectID][]models.RoundBallStats)
+	for _, ball := range balls {
+		ballsByGame[ball.GameID] = append(ballsByGame[ball.GameID], ball)
+	}
+
+	rounds := &models.CursorPage[models.RoundSummary]{
+		Items:      make([]models.RoundSummary, len(page.Items)),
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Items {
+		rounds.Items[i] = roundSummary(&page.Items[i], ballsByGame[page.Items[i].ID])
+	}
+	return rounds, nil
+}
+
+// GetRound gets the outcome of a drawn round with the bet distribution and the player's own bets
+func (s *GameService) GetRound(ctx context.Context, userID primitive.ObjectID, roundID string) (*models.RoundDetail, error) {
+	gameID, err := primitive.ObjectIDFromHex(roundID)
+	if err != nil {
+		return nil, errors.New("invalid round ID")
+	}
+
+	game, err := s.gameRepo.GetGameByID(ctx, gameID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("round not found")
+		}
+		return nil, err
+	}
+	// Nothing is revealed until the round is drawn; expired rounds never are
+	if game.Status != "completed" {
+		return nil, errors.New("round has not completed yet")
+	}
+	if game.WinningBallID == nil {
+		return nil, errors.New("round not found")
+	}
+
+	balls, err := s.gameRepo.AggregateRoundBalls(ctx, []primitive.ObjectID{gameID})
+	if err != nil {
+		return nil, err
+	}
+
+	myBets := []models.GameResult{}
+	filter := &models.GameResultFilter{UserID: &userID, GameID: &gameID}
+	err = s.gameRepo.StreamGameResults(ctx, filter, func(result *models.GameResult) error {
+		myBets = append(myBets, *result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.RoundDetail{
+		RoundSummary:   roundSummary(game, balls),
+		Balls:          balls,
+		TrajectorySeed: game.TrajectorySeed,
+		Trajectories:   game.Trajectories,
+		MyBets:         myBets,
+	}
+	if detail.Balls == nil {
+		detail.Balls = []models.RoundBallStats{}
+	}
+	if detail.Trajectories == nil {
+		detail.Trajectories = []models.BallTrajectory{}
+	}
+	return detail, nil
+}
+
+// roundSummary summarizes a drawn round from its per-ball bet totals, filling in their multipliers
+func roundSummary(game *models.Game, balls []models.RoundBallStats) models.RoundSummary {
+	summary := models.RoundSummary{
+		ID:          game.ID,
+		RoundNumber: game.RoundNumber,
+		CreatedAt:   game.CreatedAt,
+		CompletedAt: game.CompletedAt,
+	}
+
+	availableBalls := models.GetAvailableBalls()
+	availableBaskets := models.GetAvailableBaskets()
+	if game.WinningBallID != nil && *game.WinningBallID >= 0 && *game.WinningBallID < len(availableBalls) {
+		summary.WinningBallID = *game.WinningBallID
+		summary.WinningBallName = availableBalls[*game.WinningBallID].Name
+	}
+	if game.WinningBasketID != nil && *game.WinningBasketID >= 0 && *game.WinningBasketID < len(availableBaskets) {
+		summary.WinningBasketID = *game.WinningBasketID
+		summary.WinningMultiplier = availableBaskets[*game.WinningBasketID].Value
+	}
+
+	for i := range balls {
+		if balls[i].BasketLanded >= 0 && balls[i].BasketLanded < len(availableBaskets) {
+			balls[i].Multiplier = availableBaskets[balls[i].BasketLanded].Value
+		}
+		summary.Pool += balls[i].Wagered
+		summary.Bets += balls[i].Bets
+	}
+	return summary
+}
+
+// validateBallFilter checks that a ball filter names an available ball
+func validateBallFilter(ballID *int) error {
+	if ballID != nil && (*ballID < 0 || *ballID >= len(models.GetAvailableBalls())) {
+		return fmt.Errorf("invalid ball ID: %d", *ballID)
+	}
+	return nil
 }
 
-// GetUserGameStats gets game statistics for a specific user
-func (s *GameService) GetUserGameStats(ctx context.Context, userID primitive.ObjectID) (map[string]interface{}, error) {
-	return s.gameRepo.GetUserGameStats(ctx, userID)
+// decodePageCursor decodes the cursor of the previous page, or returns nil for the first page
+func decodePageCursor(cursor string) (*models.Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	return models.DecodeCursor(cursor)
+}
+
+// GetGameStats gets overall game statistics
+func (s *GameService) GetGameStats(ctx context.Context) (map[string]interface{}, error) {
+	return s.statsService.GetGameStats(ctx)
 }
 
 // SimulateOtherPlayers simulates other players placing bets (for testing)
 func (s *GameService) SimulateOtherPlayers(ctx context.Context, gameID primitive.ObjectID, numPlayers int) error {
 	availableBalls := models.GetAvailableBalls()
 	betAmounts := []float64{50, 100, 200}
+	totalAmount := 0.0
 
 	for i := 0; i < numPlayers; i++ {
 		// Create a temporary user for simulation
@@ -447,8 +732,14 @@ func (s *GameService) SimulateOtherPlayers(ctx context.Context, gameID primitive
 		if err := s.gameRepo.CreateBet(ctx, bet); err != nil {
 			return err
 		}
+		totalAmount += amount
 	}
 
+	s.auditService.Record(ctx, models.AuditActionGameSimulate, models.AuditTargetGame, gameID.Hex(), nil, map[string]interface{}{
+		"simulated_players": numPlayers,
+		"total_bet_amount":  totalAmount,
+	})
+
 	return nil
 }
 
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/config"
+	"github.com/HSouheil/bucketball_backend/models"
+)
+
+// MailboxCapacity is the number of messages the in-memory transport keeps
+const MailboxCapacity = 100
+
+// MailTransport delivers a fully built email message
+type MailTransport interface {
+	Send(from string, to []string, message []byte) error
+}
+
+// NewMailTransport creates the mail transport selected in the configuration
+func NewMailTransport(cfg *config.EmailConfig) MailTransport {
+	switch cfg.Transport {
+	case config.MailTransportFile:
+		return NewMboxTransport(cfg.MboxPath)
+	case config.MailTransportMemory:
+		return NewMemoryTransport(MailboxCapacity)
+	default:
+		return NewSMTPTransport(cfg)
+	}
+}
+
+// SMTPTransport sends messages through an SMTP server
+type SMTPTransport struct {
+	host     string
+	port     string
+	username string
+	password string
+}
+
+// NewSMTPTransport creates a new SMTP transport
+func NewSMTPTransport(cfg *config.EmailConfig) *SMTPTransport {
+	return &SMTPTransport{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+	}
+}
+
+// Send sends a message using SMTP with PLAIN authentication, upgrading to TLS when the server
+// supports it. The whole exchange must finish within EmailSMTPTimeout, so a stalled server
+// cannot hold the outbox worker or outlive the email's claim.
+func (t *SMTPTransport) Send(from string, to []string, message []byte) error {
+	addr := net.JoinHostPort(t.host, t.port)
+	conn, err := net.DialTimeout("tcp", addr, EmailSMTPTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(EmailSMTPTimeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer client.Close()
+
+	if err := t.deliver(client, from, to, message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// deliver runs the SMTP exchange the same way smtp.SendMail does
+func (t *SMTPTransport) deliver(client *smtp.Client, from string, to []string, message []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return errors.New("smtp: server doesn't support AUTH")
+	}
+	if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+		return err
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// MboxTransport appends messages to a local mbox file
+type MboxTransport struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewMboxTransport creates a new mbox transport
+func NewMboxTransport(path string) *MboxTransport {
+	return &MboxTransport{path: path}
+}
+
+// Send appends a message to the mbox file, creating it if needed
+func (t *MboxTransport) Send(from string, to []string, message []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return fmt.Errorf("failed to create mailbox directory: %w", err)
+	}
+
+	file, err := os.OpenFile(t.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open mailbox: %w", err)
+	}
+	defer file.Close()
+
+	// mbox uses LF line endings and escapes body lines that look like a message separator
+	var entry bytes.Buffer
+	fmt.Fprintf(&entry, "From %s %s\n", from, time.Now().UTC().Format(time.ANSIC))
+	for _, line := range strings.Split(strings.ReplaceAll(string(message), "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			entry.WriteString(">")
+		}
+		entry.WriteString(line)
+		entry.WriteString("\n")
+	}
+	entry.WriteString("\n")
+
+	if _, err := file.Write(entry.Bytes()); err != nil {
+		return fmt.Errorf("failed to write mailbox: %w", err)
+	}
+	return nil
+}
+
+// MemoryTransport keeps the most recent messages in memory for the dev mailbox endpoint
+type MemoryTransport struct {
+	capacity int
+	nextID   int
+	messages []models.MailboxMessage
+	mu       sync.RWMutex
+}
+
+// NewMemoryTransport creates a new in-memory transport keeping up to capacity messages
+func NewMemoryTransport(capacity int) *MemoryTransport {
+	return &MemoryTransport{capacity: capacity, nextID: 1}
+}
+
+// Send parses a message and stores it, dropping the oldest one when full
+func (t *MemoryTransport) Send(from string, to []string, message []byte) error {
+	captured, err := parseMailboxMessage(message)
+	if err != nil {
+		return fmt.Errorf("failed to parse email: %w", err)
+	}
+	captured.From = from
+	captured.To = to
+	captured.ReceivedAt = time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	captured.ID = t.nextID
+	t.nextID++
+	t.messages = append(t.messages, *captured)
+	if len(t.messages) > t.capacity {
+		t.messages = t.messages[len(t.messages)-t.capacity:]
+	}
+	return nil
+}
+
+// Messages returns the stored messages newest first, optionally only those sent to an address
+func (t *MemoryTransport) Messages(to string) []models.MailboxMessage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	messages := []models.MailboxMessage{}
+	for i := len(t.messages) - 1; i >= 0; i-- {
+		if to == "" || containsAddress(t.messages[i].To, to) {
+			messages = append(messages, t.messages[i])
+		}
+	}
+	return messages
+}
+
+// Clear removes every stored message
+func (t *MemoryTransport) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = nil
+}
+
+// parseMailboxMessage extracts the subject and the plain-text and HTML bodies of a message
+func parseMailboxMessage(message []byte) (*models.MailboxMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+
+	captured := &models.MailboxMessage{}
+	decoder := new(mime.WordDecoder)
+	if captured.Subject, err = decoder.DecodeHeader(msg.Header.Get("Subject")); err != nil {
+		return nil, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		body, err := io.ReadAll(msg.Body)
+		if err != nil {
+			return nil, err
+		}
+		captured.Text = string(body)
+		return captured, nil
+	}
+
+	// Parts are quoted-printable decoded by the multipart reader
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			captured.Text = string(body)
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			captured.HTML = string(body)
+		}
+	}
+
+	return captured, nil
+}
+
+// containsAddress checks if an address is in a recipient list, ignoring case
+func containsAddress(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if strings.EqualFold(a, address) {
+			return true
+		}
+	}
+	return false
+}
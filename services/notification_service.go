@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationService publishes in-app notifications and emails them according to user preferences
+type NotificationService struct {
+	notificationRepo *repositories.NotificationRepository
+	userRepo         *repositories.UserRepository
+	emailService     *EmailService
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(notificationRepo *repositories.NotificationRepository, userRepo *repositories.UserRepository, emailService *EmailService) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		emailService:     emailService,
+	}
+}
+
+// Publish stores a notification for a user and, if the user opted in for its category, emails it
+func (s *NotificationService) Publish(ctx context.Context, notification *models.Notification) error {
+	if !models.IsValidNotificationCategory(notification.Category) {
+		return fmt.Errorf("invalid notification category: %s", notification.Category)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, notification.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %v", err)
+	}
+
+	notification.Read = false
+	notification.ReadAt = nil
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to store notification: %v", err)
+	}
+
+	// The in-app notification is stored, so an email failure is only reported
+	if user.IsEmailVerified && user.WantsEmailNotification(notification.Category) {
+		if err := s.sendEmail(user, notification); err != nil {
+			fmt.Printf("Warning: failed to email notification: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// sendEmail emails a notification, using the dedicated template for the types that have one
+func (s *NotificationService) sendEmail(user *models.User, notification *models.Notification) error {
+	amount, _ := notification.Data["amount"].(float64)
+
+	switch notification.Type {
+	case "withdrawal_processed":
+		balance, _ := notification.Data["balance"].(float64)
+		return s.emailService.SendWithdrawalStatusEmail(user.Email, user.Username, user.Language, "completed", amount, balance, "")
+	case "big_win":
+		multiplier, _ := notification.Data["multiplier"].(float64)
+		return s.emailService.SendBigWinEmail(user.Email, user.Username, user.Language, amount, multiplier)
+	default:
+		return s.emailService.SendNotificationEmail(user.Email, user.Username, user.Language, notification.Title, notification.Message)
+	}
+}
+
+// GetNotifications gets a page of a user's notifications with their unread count
+func (s *NotificationService) GetNotifications(ctx context.Context, userID primitive.ObjectID, category string, unreadOnly bool, page, limit int64) ([]models.Notification, int64, int64, error) {
+	if category != "" && !models.IsValidNotificationCategory(category) {
+		return nil, 0, 0, errors.New("invalid category")
+	}
+
+	skip, limit := pageBounds(page, limit)
+
+	notifications, err := s.notificationRepo.ListByUser(ctx, userID, category, unreadOnly, skip, limit)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	total, err := s.notificationRepo.CountByUser(ctx, userID, category, unreadOnly)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	unread, err := s.notificationRepo.CountByUser(ctx, userID, "", true)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return notifications, total, unread, nil
+}
+
+// GetUnreadCount counts a user's unread notifications
+func (s *NotificationService) GetUnreadCount(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return s.notificationRepo.CountByUser(ctx, userID, "", true)
+}
+
+// MarkRead marks one of a user's notifications as read
+func (s *NotificationService) MarkRead(ctx context.Context, userID primitive.ObjectID, notificationID string) error {
+	objectID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return errors.New("invalid notification ID")
+	}
+
+	exists, err := s.notificationRepo.Exists(ctx, userID, objectID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("notification not found")
+	}
+
+	_, err = s.notificationRepo.MarkRead(ctx, userID, []primitive.ObjectID{objectID})
+	return err
+}
+
+// MarkAllRead marks the given notifications as read, or all of a user's notifications when none are given
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID primitive.ObjectID, notificationIDs []string) (int64, error) {
+	ids := make([]primitive.ObjectID, 0, len(notificationIDs))
+	for _, id := range notificationIDs {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return 0, errors.New("invalid notification ID")
+		}
+		ids = append(ids, objectID)
+	}
+
+	return s.notificationRepo.MarkRead(ctx, userID, ids)
+}
+
+// GetPreferences gets whether each notification category is emailed to a user
+func (s *NotificationService) GetPreferences(ctx context.Context, userID primitive.ObjectID) (map[string]bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return emailPreferences(user), nil
+}
+
+// UpdatePreferences changes which notification categories are emailed to a user
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID primitive.ObjectID, email map[string]bool) (map[string]bool, error) {
+	updateData := make(map[string]interface{})
+	for category, enabled := range email {
+		if !models.IsValidNotificationCategory(category) {
+			return nil, fmt.Errorf("invalid category: %s", category)
+		}
+		updateData["email_notifications."+category] = enabled
+	}
+
+	if err := s.userRepo.Update(ctx, userID, updateData); err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
+
+// emailPreferences resolves a user's email preference for every category
+func emailPreferences(user *models.User) map[string]bool {
+	preferences := make(map[string]bool, len(models.NotificationCategories))
+	for _, category := range models.NotificationCategories {
+		preferences[category] = user.WantsEmailNotification(category)
+	}
+	return preferences
+}
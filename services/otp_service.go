@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"math/big"
@@ -16,7 +17,9 @@ import (
 const (
 	OTPTypeRegistration  = "registration"
 	OTPTypePasswordReset = "password_reset"
+	OTPTypeEmailChange   = "email_change"
 	OTPExpiryMinutes     = 10
+	OTPMaxAttempts       = 5 // a code is invalidated after this many wrong guesses
 )
 
 // OTPService handles OTP operations
@@ -33,8 +36,8 @@ func NewOTPService(otpRepo *repositories.OTPRepository, emailService *EmailServi
 	}
 }
 
-// GenerateAndSendOTP generates a 6-digit OTP and sends it via email
-func (s *OTPService) GenerateAndSendOTP(ctx context.Context, email, username, otpType string) error {
+// GenerateAndSendOTP generates a 6-digit OTP and sends it via email in the recipient's locale
+func (s *OTPService) GenerateAndSendOTP(ctx context.Context, email, username, locale, otpType string) error {
 	// Generate 6-digit OTP
 	code, err := s.generateOTP()
 	if err != nil {
@@ -61,15 +64,24 @@ func (s *OTPService) GenerateAndSendOTP(ctx context.Context, email, username, ot
 		return fmt.Errorf("failed to save OTP: %w", err)
 	}
 
-	// Send OTP via email
-	if err := s.emailService.SendOTPEmail(email, username, code); err != nil {
+	// Send OTP via email using the template for its purpose
+	switch otpType {
+	case OTPTypePasswordReset:
+		err = s.emailService.SendPasswordResetEmail(email, username, locale, code)
+	case OTPTypeEmailChange:
+		err = s.emailService.SendEmailChangeEmail(email, username, locale, code)
+	default:
+		err = s.emailService.SendOTPEmail(email, username, locale, code)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to send OTP email: %w", err)
 	}
 
 	return nil
 }
 
-// VerifyOTP verifies an OTP code
+// VerifyOTP verifies an OTP code. Each attempt is counted on the code before it is compared,
+// so at most OTPMaxAttempts guesses are checked whatever IP they come from.
 func (s *OTPService) VerifyOTP(ctx context.Context, email, code, otpType string) error {
 	// Get latest OTP for this email and type
 	otp, err := s.otpRepo.GetLatestByEmailAndType(ctx, email, otpType)
@@ -80,11 +92,6 @@ func (s *OTPService) VerifyOTP(ctx context.Context, email, code, otpType string)
 		return fmt.Errorf("failed to retrieve OTP: %w", err)
 	}
 
-	// Check if OTP matches
-	if otp.Code != code {
-		return errors.New("invalid OTP code")
-	}
-
 	// Check if OTP is already used
 	if otp.IsUsed {
 		return errors.New("OTP has already been used")
@@ -95,10 +102,27 @@ func (s *OTPService) VerifyOTP(ctx context.Context, email, code, otpType string)
 		return errors.New("OTP has expired")
 	}
 
-	// Mark OTP as used
-	if err := s.otpRepo.MarkAsUsed(ctx, email, code, otpType); err != nil {
+	otp, err = s.otpRepo.ClaimAttempt(ctx, otp.ID, OTPMaxAttempts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("OTP has been invalidated after too many failed attempts, request a new one")
+		}
+		return fmt.Errorf("failed to record OTP attempt: %w", err)
+	}
+
+	// Check if OTP matches
+	if subtle.ConstantTimeCompare([]byte(otp.Code), []byte(code)) != 1 {
+		return errors.New("invalid OTP code")
+	}
+
+	// Mark OTP as used; a concurrent verification may have used it first
+	used, err := s.otpRepo.MarkAsUsed(ctx, otp.ID)
+	if err != nil {
 		return fmt.Errorf("failed to mark OTP as used: %w", err)
 	}
+	if !used {
+		return errors.New("OTP has already been used")
+	}
 
 	return nil
 }
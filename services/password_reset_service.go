@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/security"
+	"github.com/HSouheil/bucketball_backend/utils"
+)
+
+// ForgotPassword sends a password reset OTP if an active account exists for the email.
+// It never reports whether the account exists so the endpoint cannot be used for enumeration.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	email = utils.SanitizeEmail(email)
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil || !user.IsActive {
+		return nil
+	}
+
+	if err := s.otpService.GenerateAndSendOTP(ctx, user.Email, user.Username, user.Language, OTPTypePasswordReset); err != nil {
+		// Log the error but keep the response identical to the unknown-account case
+		fmt.Printf("Warning: failed to send password reset OTP: %v\n", err)
+	}
+
+	return nil
+}
+
+// ResetPassword verifies a password reset OTP, sets the new password and revokes every session
+func (s *AuthService) ResetPassword(ctx context.Context, email, otp, newPassword string) error {
+	email = utils.SanitizeEmail(email)
+
+	// Any OTP failure is reported the same way so responses don't reveal whether the account exists
+	if err := s.otpService.VerifyOTP(ctx, email, otp, OTPTypePasswordReset); err != nil {
+		return errors.New("invalid or expired reset code")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return errors.New("invalid or expired reset code")
+	}
+
+	hashedPassword, err := security.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	updateData := map[string]interface{}{
+		"password":   hashedPassword,
+		"updated_at": time.Now(),
+	}
+
+	if err := s.userRepo.Update(ctx, user.ID, updateData); err != nil {
+		return errors.New("failed to update password")
+	}
+
+	if err := s.sessionService.RevokeAllSessions(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+
+	// The user proved ownership of the account, so lift any login lockout
+	s.rateLimitSvc.ResetLoginAttempts(ctx, user.Email, "")
+
+	return nil
+}
@@ -2,24 +2,29 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
 	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/HSouheil/bucketball_backend/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type PaymentService struct {
-	userRepo        *repositories.UserRepository
-	referralService *ReferralService
+	userRepo            *repositories.UserRepository
+	transactionRepo     *repositories.TransactionRepository
+	referralService     *ReferralService
+	notificationService *NotificationService
 }
 
 // NewPaymentService creates a new payment service
-func NewPaymentService(userRepo *repositories.UserRepository, referralService *ReferralService) *PaymentService {
+func NewPaymentService(userRepo *repositories.UserRepository, transactionRepo *repositories.TransactionRepository, referralService *ReferralService, notificationService *NotificationService) *PaymentService {
 	return &PaymentService{
-		userRepo:        userRepo,
-		referralService: referralService,
+		userRepo:            userRepo,
+		transactionRepo:     transactionRepo,
+		referralService:     referralService,
+		notificationService: notificationService,
 	}
 }
 
@@ -32,15 +37,14 @@ func (ps *PaymentService) ProcessPayment(ctx context.Context, userID primitive.O
 	}
 
 	// Update user's balance
-	updateData := map[string]interface{}{
-		"balance":    user.Balance + amount,
-		"updated_at": time.Now(),
-	}
-
-	if err := ps.userRepo.Update(ctx, user.ID, updateData); err != nil {
+	updated, err := ps.userRepo.AdjustBalance(ctx, user.ID, amount)
+	if err != nil {
 		return fmt.Errorf("failed to update user balance: %v", err)
 	}
 
+	// Record the deposit; analytics counts first deposits from these
+	ps.recordTransaction(ctx, user.ID, "deposit", amount, updated.Balance, description)
+
 	// Process referral commission if applicable
 	if err := ps.referralService.ProcessReferralCommission(ctx, userID, amount); err != nil {
 		// Log the error but don't fail the payment
@@ -51,6 +55,17 @@ func (ps *PaymentService) ProcessPayment(ctx context.Context, userID primitive.O
 	fmt.Printf("Payment processed: User %s received $%.2f. Description: %s\n",
 		user.Email, amount, description)
 
+	if err := ps.notificationService.Publish(ctx, &models.Notification{
+		UserID:   user.ID,
+		Category: models.NotificationCategoryPayment,
+		Type:     "deposit_completed",
+		Title:    fmt.Sprintf("Deposit of $%.2f received", amount),
+		Message:  fmt.Sprintf("$%.2f has been added to your balance. Your new balance is $%.2f.", amount, updated.Balance),
+		Data:     map[string]interface{}{"amount": amount},
+	}); err != nil {
+		fmt.Printf("Warning: failed to publish deposit notification: %v\n", err)
+	}
+
 	return nil
 }
 
@@ -67,27 +82,73 @@ func (ps *PaymentService) ProcessWithdrawal(ctx context.Context, userID primitiv
 		return fmt.Errorf("insufficient balance")
 	}
 
-	// Update user's balance and withdrawal amount
-	updateData := map[string]interface{}{
-		"balance":    user.Balance - amount,
-		"withdraw":   user.Withdraw + amount,
-		"updated_at": time.Now(),
-	}
-
-	if err := ps.userRepo.Update(ctx, user.ID, updateData); err != nil {
+	// Update user's balance, only if it still covers the amount, and withdrawal amount
+	updated, err := ps.userRepo.AdjustBalance(ctx, user.ID, -amount)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("insufficient balance")
+		}
 		return fmt.Errorf("failed to process withdrawal: %v", err)
 	}
+	if err := ps.userRepo.Increment(ctx, user.ID, map[string]float64{"withdraw": amount}); err != nil {
+		fmt.Printf("Warning: failed to update withdrawal total: %v\n", err)
+	}
+
+	ps.recordTransaction(ctx, user.ID, "withdrawal", -amount, updated.Balance, "Withdrawal")
 
 	// Log the withdrawal
 	fmt.Printf("Withdrawal processed: User %s withdrew $%.2f to account %s\n",
 		user.Email, amount, bankAccount)
 
+	if err := ps.notificationService.Publish(ctx, &models.Notification{
+		UserID:   user.ID,
+		Category: models.NotificationCategoryPayment,
+		Type:     "withdrawal_processed",
+		Title:    fmt.Sprintf("Withdrawal of $%.2f processed", amount),
+		Message:  fmt.Sprintf("$%.2f has been withdrawn from your balance. Your new balance is $%.2f.", amount, updated.Balance),
+		Data:     map[string]interface{}{"amount": amount, "balance": updated.Balance},
+	}); err != nil {
+		fmt.Printf("Warning: failed to publish withdrawal notification: %v\n", err)
+	}
+
 	return nil
 }
 
-// GetPaymentHistory gets payment history for a user (placeholder implementation)
-func (ps *PaymentService) GetPaymentHistory(ctx context.Context, userID primitive.ObjectID) ([]models.Transaction, error) {
-	// This would typically query a transactions collection
-	// For now, return empty slice
-	return []models.Transaction{}, nil
+// recordTransaction stores a completed deposit or withdrawal with its signed amount. The balance
+// has already moved, so a failure is logged rather than returned.
+func (ps *PaymentService) recordTransaction(ctx context.Context, userID primitive.ObjectID, transactionType string, amount, balance float64, description string) {
+	transaction := &models.Transaction{
+		UserID:      userID,
+		Type:        transactionType,
+		Amount:      amount,
+		Balance:     balance,
+		Description: description,
+		Status:      "completed",
+	}
+	if err := ps.transactionRepo.Create(ctx, transaction); err != nil {
+		fmt.Printf("Warning: failed to record %s transaction: %v\n", transactionType, err)
+	}
+}
+
+// GetPaymentHistory gets a page of a user's transactions, newest first, after an optional cursor
+func (ps *PaymentService) GetPaymentHistory(ctx context.Context, userID primitive.ObjectID, filter *models.TransactionFilter, cursor string, limit int64) (*models.CursorPage[models.Transaction], error) {
+	switch filter.Type {
+	case "", "deposit", "withdrawal", "transfer", "adjustment":
+	default:
+		return nil, errors.New("invalid type: must be deposit, withdrawal, transfer or adjustment")
+	}
+	after, err := decodePageCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.UserID = &userID
+	transactions, err := ps.transactionRepo.ListAfter(ctx, filter, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewCursorPage(transactions, limit, func(t *models.Transaction) *models.Cursor {
+		return &models.Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+	}), nil
 }
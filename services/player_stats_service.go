@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PlayerStatsService struct {
+	statsRepo *repositories.StatsRepository
+}
+
+// NewPlayerStatsService creates a new player stats service
+func NewPlayerStatsService(statsRepo *repositories.StatsRepository) *PlayerStatsService {
+	return &PlayerStatsService{
+		statsRepo: statsRepo,
+	}
+}
+
+// GetPlayerStats gets a player's statistics from their daily rollups, covering every UTC day from the
+// day of from through the day of to (both optional). The returned range is the one actually covered:
+// from the start of the first day to the start of the day after the last. Streaks follow the order
+// bets settled in; a push ends both the current win and loss streak.
+func (s *PlayerStatsService) GetPlayerStats(ctx context.Context, userID primitive.ObjectID, from, to *time.Time) (*models.PlayerStats, error) {
+	if from != nil && to != nil && from.After(*to) {
+		return nil, errors.New("invalid date range: from must be before to")
+	}
+
+	stats := &models.PlayerStats{}
+	var firstDay, lastDay *time.Time
+	if from != nil {
+		day := statsDay(*from)
+		firstDay = &day
+		stats.From = &day
+	}
+	if to != nil {
+		day := statsDay(*to)
+		lastDay = &day
+		end := day.AddDate(0, 0, 1)
+		stats.To = &end
+	}
+
+	days, err := s.statsRepo.ListUserDays(ctx, userID, firstDay, lastDay)
+	if err != nil {
+		return nil, err
+	}
+
+	balls := make(map[int]*models.PlayerBallStats)
+	var streaks models.StatsStreaks
+	for i := range days {
+		day := &days[i]
+		stats.Rounds += day.Rounds
+		stats.Bets += day.Bets
+		stats.Wins += day.Wins
+		stats.Pushes += day.Pushes
+		stats.Wagered += day.Wagered
+		stats.Payouts += day.Payouts
+		stats.NetProfit += day.Profit
+		if day.BiggestWin != nil && (stats.BiggestWin == nil || day.BiggestWin.Profit > stats.BiggestWin.Profit) {
+			stats.BiggestWin = day.BiggestWin
+		}
+		streaks = streaks.Then(day.Streaks)
+
+		for key, dayBall := range day.Balls {
+			ballID, err := strconv.Atoi(key)
+			if err != nil {
+				continue
+			}
+			ball := balls[ballID]
+			if ball == nil {
+				ball = &models.PlayerBallStats{BallID: ballID, BallName: ballName(ballID)}
+				balls[ballID] = ball
+			}
+			ball.Bets += dayBall.Bets
+			ball.Wins += dayBall.Wins
+			ball.Pushes += dayBall.Pushes
+			ball.Wagered += dayBall.Wagered
+			ball.Payouts += dayBall.Payouts
+			ball.NetProfit += dayBall.Profit
+		}
+	}
+
+	stats.Losses = stats.Bets - stats.Wins - stats.Pushes
+	stats.LongestWinStreak = streaks.LongestWin
+	stats.LongestLossStreak = streaks.LongestLoss
+	if stats.Bets > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.Bets) * 100
+		stats.PushRate = float64(stats.Pushes) / float64(stats.Bets) * 100
+		stats.AverageBet = stats.Wagered / float64(stats.Bets)
+	}
+	if stats.Wagered > 0 {
+		stats.ROI = stats.NetProfit / stats.Wagered * 100
+	}
+
+	stats.Balls = make([]models.PlayerBallStats, 0, len(balls))
+	for _, ball := range balls {
+		ball.Losses = ball.Bets - ball.Wins - ball.Pushes
+		if ball.Bets > 0 {
+			ball.WinRate = float64(ball.Wins) / float64(ball.Bets) * 100
+		}
+		stats.Balls = append(stats.Balls, *ball)
+	}
+	sort.Slice(stats.Balls, func(i, j int) bool { return stats.Balls[i].BallID < stats.Balls[j].BallID })
+
+	return stats, nil
+}
+
+// ballName gets the name of a ball from the catalog
+func ballName(ballID int) string {
+	for _, ball := range models.GetAvailableBalls() {
+		if ball.ID == ballID {
+			return ball.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/HSouheil/bucketball_backend/security"
+	"github.com/HSouheil/bucketball_backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProfilePicDirectory is where uploaded profile pictures are stored
+const ProfilePicDirectory = "uploads/users"
+
+// PrivacyService handles personal data export and erasure requests
+type PrivacyService struct {
+	userRepo         *repositories.UserRepository
+	gameRepo         *repositories.GameRepository
+	transactionRepo  *repositories.TransactionRepository
+	referralRepo     *repositories.ReferralRepository
+	notificationRepo *repositories.NotificationRepository
+	otpRepo          *repositories.OTPRepository
+	outboxRepo       *repositories.EmailOutboxRepository
+	sessionService   *SessionService
+	auditService     *AuditService
+}
+
+// NewPrivacyService creates a new privacy service
+func NewPrivacyService(userRepo *repositories.UserRepository, gameRepo *repositories.GameRepository, transactionRepo *repositories.TransactionRepository, referralRepo *repositories.ReferralRepository, notificationRepo *repositories.NotificationRepository, otpRepo *repositories.OTPRepository, outboxRepo *repositories.EmailOutboxRepository, sessionService *SessionService, auditService *AuditService) *PrivacyService {
+	return &PrivacyService{
+		userRepo:         userRepo,
+		gameRepo:         gameRepo,
+		transactionRepo:  transactionRepo,
+		referralRepo:     referralRepo,
+		notificationRepo: notificationRepo,
+		otpRepo:          otpRepo,
+		outboxRepo:       outboxRepo,
+		sessionService:   sessionService,
+		auditService:     auditService,
+	}
+}
+
+// ExportData collects a user's profile, bets, results, transactions, referrals and notifications
+func (s *PrivacyService) ExportData(ctx context.Context, userID primitive.ObjectID) (*models.UserDataExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	export := &models.UserDataExport{
+		GeneratedAt: time.Now(),
+		Profile:     user,
+	}
+
+	// A limit of 0 returns every record
+	if export.Bets, err = s.gameRepo.GetBetsByUserID(ctx, userID, 0); err != nil {
+		return nil, fmt.Errorf("failed to export bets: %v", err)
+	}
+	if export.GameResults, err = s.gameRepo.GetGameResultsByUserID(ctx, userID, 0); err != nil {
+		return nil, fmt.Errorf("failed to export game results: %v", err)
+	}
+	if export.Transactions, err = s.transactionRepo.ListByUser(ctx, userID, 0, 0); err != nil {
+		return nil, fmt.Errorf("failed to export transactions: %v", err)
+	}
+	if export.ReferralCommissions, err = s.referralRepo.ListByReferrer(ctx, userID, 0, 0); err != nil {
+		return nil, fmt.Errorf("failed to export referral commissions: %v", err)
+	}
+	if export.Notifications, err = s.notificationRepo.ListByUser(ctx, userID, "", false, 0, 0); err != nil {
+		return nil, fmt.Errorf("failed to export notifications: %v", err)
+	}
+
+	// Export empty sections as [] rather than null
+	if export.Bets == nil {
+		export.Bets = []models.Bet{}
+	}
+	if export.GameResults == nil {
+		export.GameResults = []models.GameResult{}
+	}
+
+	referred, err := s.userRepo.ListByReferrer(ctx, userID, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export referrals: %v", err)
+	}
+	// Referred users are other people, so only what the referral dashboard already shows is included
+	export.ReferredUsers = make([]models.ReferredUser, 0, len(referred))
+	for _, u := range referred {
+		export.ReferredUsers = append(export.ReferredUsers, models.ReferredUser{
+			ID:              u.ID,
+			Username:        utils.MaskString(u.Username),
+			Email:           utils.MaskEmail(u.Email),
+			IsActive:        u.IsActive,
+			IsEmailVerified: u.IsEmailVerified,
+			JoinedAt:        u.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// WriteExportArchive writes a data export as a ZIP archive with one JSON file per section
+func (s *PrivacyService) WriteExportArchive(w io.Writer, export *models.UserDataExport) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"bets.json", export.Bets},
+		{"game_results.json", export.GameResults},
+		{"transactions.json", export.Transactions},
+		{"referral_commissions.json", export.ReferralCommissions},
+		{"referred_users.json", export.ReferredUsers},
+		{"notifications.json", export.Notifications},
+	}
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// DeleteAccount erases the current user's personal data after checking their password
+func (s *PrivacyService) DeleteAccount(ctx context.Context, userID primitive.ObjectID, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !security.CheckPasswordHash(password, user.Password) {
+		return errors.New("password is incorrect")
+	}
+
+	return s.EraseUser(ctx, user)
+}
+
+// EraseUser anonymizes a user's personal fields in place so bets, results and transactions stay
+// consistent, then removes their profile picture, OTPs, notifications, queued emails and sessions
+func (s *PrivacyService) EraseUser(ctx context.Context, user *models.User) error {
+	if user.DeletedAt != nil {
+		return errors.New("user already deleted")
+	}
+
+	placeholder := "deleted_" + user.ID.Hex()
+	replacements := map[string]interface{}{
+		"email":              placeholder + "@deleted.invalid",
+		"username":           placeholder,
+		"password":           "",
+		"first_name":         "Deleted",
+		"last_name":          "User",
+		"profile_pic":        "",
+		"phone_number":       "",
+		"location":           models.Location{},
+		"is_active":          false,
+		"is_email_verified":  false,
+		"two_factor_enabled": false,
+		"referral_code":      placeholder,
+		"deleted_at":         time.Now(),
+	}
+	removed := []string{
+		"dob",
+		"two_factor_secret",
+		"two_factor_pending_secret",
+		"two_factor_recovery_codes",
+		"pending_email",
+		"language",
+		"email_notifications",
+	}
+	if err := s.userRepo.Anonymize(ctx, user.ID, replacements, removed); err != nil {
+		return err
+	}
+
+	// The account is anonymized; leftovers elsewhere are cleaned up on a best-effort basis
+	if isUploadedProfilePic(user.ProfilePic) {
+		if err := utils.DeleteFile(user.ProfilePic); err != nil {
+			fmt.Printf("Warning: failed to delete profile picture: %v\n", err)
+		}
+	}
+	for _, email := range []string{user.Email, user.PendingEmail} {
+		if email == "" {
+			continue
+		}
+		if err := s.otpRepo.DeleteByEmail(ctx, email); err != nil {
+			fmt.Printf("Warning: failed to delete OTPs: %v\n", err)
+		}
+		if err := s.outboxRepo.DeleteByRecipient(ctx, email); err != nil {
+			fmt.Printf("Warning: failed to delete queued emails: %v\n", err)
+		}
+	}
+	if err := s.notificationRepo.DeleteByUser(ctx, user.ID); err != nil {
+		fmt.Printf("Warning: failed to delete notifications: %v\n", err)
+	}
+	if err := s.sessionService.RevokeAllSessions(ctx, user.ID.Hex()); err != nil {
+		fmt.Printf("Warning: failed to revoke sessions: %v\n", err)
+	}
+
+	// Personal fields are left out so the audit log does not keep what was just erased
+	s.auditService.Record(ctx, models.AuditActionUserDelete, models.AuditTargetUser, user.ID.Hex(), nil, map[string]interface{}{
+		"erased":  true,
+		"role":    user.Role,
+		"balance": user.Balance,
+	})
+
+	return nil
+}
+
+// isUploadedProfilePic checks that a profile picture path points inside the upload directory,
+// since the field can be set freely through the profile endpoint
+func isUploadedProfilePic(path string) bool {
+	if path == "" || filepath.IsAbs(path) {
+		return false
+	}
+	clean := filepath.Clean(path)
+	return strings.HasPrefix(clean, filepath.Clean(ProfilePicDirectory)+string(filepath.Separator))
+}
@@ -6,22 +6,33 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"math"
+	"strconv"
 	"time"
 
 	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/HSouheil/bucketball_backend/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ActiveReferralWindow is how recently a referred user must have generated a commission to count as active
+const ActiveReferralWindow = 30 * 24 * time.Hour
+
 type ReferralService struct {
-	userRepo *repositories.UserRepository
+	userRepo            *repositories.UserRepository
+	referralRepo        *repositories.ReferralRepository
+	auditService        *AuditService
+	notificationService *NotificationService
 }
 
 // NewReferralService creates a new referral service
-func NewReferralService(userRepo *repositories.UserRepository) *ReferralService {
+func NewReferralService(userRepo *repositories.UserRepository, referralRepo *repositories.ReferralRepository, auditService *AuditService, notificationService *NotificationService) *ReferralService {
 	return &ReferralService{
-		userRepo: userRepo,
+		userRepo:            userRepo,
+		referralRepo:        referralRepo,
+		auditService:        auditService,
+		notificationService: notificationService,
 	}
 }
 
@@ -66,78 +77,197 @@ func (rs *ReferralService) ValidateReferralCode(ctx context.Context, referralCod
 	return referrer, nil
 }
 
-// CalculateCommission calculates the commission amount based on the payment amount
-func (rs *ReferralService) CalculateCommission(paymentAmount float64) float64 {
-	// 0.5% commission for every full $100 paid
-	// Only pay commission for complete $100 increments
-	fullHundreds := math.Floor(paymentAmount / 100.0)
-	if fullHundreds < 1 {
-		return 0 // No commission for amounts under $100
+// GetActiveProgram gets the referral program currently in force
+func (rs *ReferralService) GetActiveProgram(ctx context.Context) (*models.ReferralProgram, error) {
+	program, err := rs.referralRepo.GetActiveProgram(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	commissionRate := 0.005 // 0.5%
-	commissionAmount := fullHundreds * 100 * commissionRate
-	return math.Round(commissionAmount*100) / 100 // Round to 2 decimal places
+	// Fall back to the built-in rules until an admin publishes a program
+	if program == nil {
+		return models.DefaultReferralProgram(), nil
+	}
+
+	return program, nil
 }
 
-// ProcessReferralCommission processes a referral commission when a referred user pays
+// ProcessReferralCommission processes referral commissions when a referred user pays
 func (rs *ReferralService) ProcessReferralCommission(ctx context.Context, referredUserID primitive.ObjectID, paymentAmount float64) error {
+	program, err := rs.GetActiveProgram(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get referral program: %v", err)
+	}
+
+	// Deposits only earn commission under the deposit program
+	if program.Mode != models.ReferralModeDeposit {
+		return nil
+	}
+
+	return rs.payReferralChain(ctx, program, referredUserID, nil, paymentAmount)
+}
+
+// ProcessRevenueShare processes referral commissions on a referred user's net loss in a settled game
+func (rs *ReferralService) ProcessRevenueShare(ctx context.Context, referredUserID, gameID primitive.ObjectID, netLoss float64) error {
+	if netLoss <= 0 {
+		return nil
+	}
+
+	program, err := rs.GetActiveProgram(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get referral program: %v", err)
+	}
+
+	// Settled losses only earn commission under the revenue share program
+	if program.Mode != models.ReferralModeRevenueShare {
+		return nil
+	}
+
+	return rs.payReferralChain(ctx, program, referredUserID, &gameID, netLoss)
+}
+
+// payReferralChain walks up the referral chain of a user and pays each configured level
+func (rs *ReferralService) payReferralChain(ctx context.Context, program *models.ReferralProgram, referredUserID primitive.ObjectID, gameID *primitive.ObjectID, baseAmount float64) error {
 	// Get the referred user to find their referrer
 	referredUser, err := rs.userRepo.GetByID(ctx, referredUserID)
 	if err != nil {
 		return fmt.Errorf("failed to get referred user: %v", err)
 	}
 
-	// Check if user was referred by someone
-	if referredUser.ReferredBy == nil {
-		return nil // No referrer, no commission
+	rates := make(map[int]float64, len(program.Tiers))
+	for _, tier := range program.Tiers {
+		rates[tier.Level] = tier.Rate
 	}
 
-	// Calculate commission
-	commissionAmount := rs.CalculateCommission(paymentAmount)
-	if commissionAmount <= 0 {
-		return nil // No commission for small amounts
+	// Track visited users so a referral cycle cannot pay anyone twice
+	visited := map[primitive.ObjectID]bool{referredUser.ID: true}
+	current := referredUser
+
+	for level := 1; level <= len(program.Tiers); level++ {
+		if current.ReferredBy == nil || visited[*current.ReferredBy] {
+			break
+		}
+
+		referrer, err := rs.userRepo.GetByID(ctx, *current.ReferredBy)
+		if err != nil {
+			return fmt.Errorf("failed to get level %d referrer: %v", level, err)
+		}
+		visited[referrer.ID] = true
+		current = referrer
+
+		rate := rates[level]
+		commissionAmount := program.CalculateCommission(baseAmount, rate)
+		if commissionAmount <= 0 {
+			continue
+		}
+
+		// Update referrer's balance and referral earnings
+		increments := map[string]float64{
+			"balance":           commissionAmount,
+			"referral_earnings": commissionAmount,
+		}
+		if err := rs.userRepo.Increment(ctx, referrer.ID, increments); err != nil {
+			return fmt.Errorf("failed to update referrer balance: %v", err)
+		}
+
+		description := fmt.Sprintf("Level %d referral commission from %s's payment", level, utils.MaskEmail(referredUser.Email))
+		if program.Mode == models.ReferralModeRevenueShare {
+			description = fmt.Sprintf("Level %d revenue share from %s's play", level, utils.MaskEmail(referredUser.Email))
+		}
+
+		// Create commission record
+		commission := &models.ReferralCommission{
+			ReferrerID:       referrer.ID,
+			ReferredUserID:   referredUser.ID,
+			GameID:           gameID,
+			Level:            level,
+			Source:           program.Mode,
+			ProgramVersion:   program.Version,
+			OriginalAmount:   baseAmount,
+			CommissionRate:   rate,
+			CommissionAmount: commissionAmount,
+			Description:      description,
+			Status:           "completed",
+			CreatedAt:        time.Now(),
+			UpdatedAt:        time.Now(),
+		}
+
+		if err := rs.referralRepo.CreateCommission(ctx, commission); err != nil {
+			return fmt.Errorf("failed to store referral commission: %v", err)
+		}
+
+		if err := rs.notificationService.Publish(ctx, &models.Notification{
+			UserID:   referrer.ID,
+			Category: models.NotificationCategoryReferral,
+			Type:     "commission_earned",
+			Title:    fmt.Sprintf("You earned $%.2f in referral commission", commissionAmount),
+			Message:  description + ".",
+			Data: map[string]interface{}{
+				"commission_id": commission.ID.Hex(),
+				"amount":        commissionAmount,
+				"level":         level,
+			},
+		}); err != nil {
+			fmt.Printf("Warning: failed to publish commission notification: %v\n", err)
+		}
 	}
 
-	// Get the referrer
-	referrer, err := rs.userRepo.GetByID(ctx, *referredUser.ReferredBy)
-	if err != nil {
-		return fmt.Errorf("failed to get referrer: %v", err)
+	return nil
+}
+
+// ListPrograms gets every published referral program version (admin only)
+func (rs *ReferralService) ListPrograms(ctx context.Context) ([]models.ReferralProgram, error) {
+	return rs.referralRepo.ListPrograms(ctx)
+}
+
+// PublishProgram publishes a new referral program version and makes it active (admin only)
+func (rs *ReferralService) PublishProgram(ctx context.Context, adminID primitive.ObjectID, req *models.ReferralProgramRequest) (*models.ReferralProgram, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
 
-	// Update referrer's balance and referral earnings
-	updateData := map[string]interface{}{
-		"balance":           referrer.Balance + commissionAmount,
-		"referral_earnings": referrer.ReferralEarnings + commissionAmount,
-		"updated_at":        time.Now(),
+	program := &models.ReferralProgram{
+		Mode:      req.Mode,
+		Tiers:     req.Tiers,
+		Increment: req.Increment,
+		Notes:     utils.SanitizeString(req.Notes),
+		CreatedBy: &adminID,
 	}
 
-	if err := rs.userRepo.Update(ctx, referrer.ID, updateData); err != nil {
-		return fmt.Errorf("failed to update referrer balance: %v", err)
+	if err := rs.referralRepo.CreateProgram(ctx, program); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("referral program version conflict, please retry")
+		}
+		return nil, err
 	}
 
-	// Create commission record
-	commission := &models.ReferralCommission{
-		ReferrerID:       referrer.ID,
-		ReferredUserID:   referredUserID,
-		OriginalAmount:   paymentAmount,
-		CommissionRate:   0.005, // 0.5%
-		CommissionAmount: commissionAmount,
-		Description:      fmt.Sprintf("Referral commission from %s's payment", referredUser.Email),
-		Status:           "completed",
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+	rs.auditService.Record(ctx, models.AuditActionReferralProgramPublish, models.AuditTargetReferralProgram, strconv.Itoa(program.Version), nil, map[string]interface{}{
+		"mode":      program.Mode,
+		"tiers":     program.Tiers,
+		"increment": program.Increment,
+	})
+
+	return program, nil
+}
+
+// ActivateProgram makes an earlier referral program version active again (admin only)
+func (rs *ReferralService) ActivateProgram(ctx context.Context, version int) (*models.ReferralProgram, error) {
+	previous, err := rs.GetActiveProgram(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Store commission record (you might want to create a separate repository for this)
-	// For now, we'll just log it
-	fmt.Printf("Referral Commission: Referrer %s earned $%.2f from referred user %s's payment of $%.2f\n",
-		referrer.Email, commissionAmount, referredUser.Email, paymentAmount)
+	if err := rs.referralRepo.ActivateProgram(ctx, version); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("referral program not found")
+		}
+		return nil, err
+	}
 
-	// Log commission details for debugging
-	_ = commission // Suppress unused variable warning
+	rs.auditService.Record(ctx, models.AuditActionReferralProgramActivate, models.AuditTargetReferralProgram, strconv.Itoa(version),
+		map[string]interface{}{"active_version": previous.Version}, map[string]interface{}{"active_version": version})
 
-	return nil
+	return rs.referralRepo.GetProgramByVersion(ctx, version)
 }
 
 // GetReferralStats gets referral statistics for a user
@@ -147,13 +277,106 @@ func (rs *ReferralService) GetReferralStats(ctx context.Context, userID primitiv
 		return nil, err
 	}
 
-	// Get total referrals count (you might want to implement this in the repository)
-	// For now, we'll return basic stats
+	referralCount, err := rs.userRepo.CountByReferrer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	stats := map[string]interface{}{
 		"referral_code":     user.ReferralCode,
+		"referral_count":    referralCount,
 		"referral_earnings": user.ReferralEarnings,
 		"total_earnings":    user.Balance,
 	}
 
 	return stats, nil
 }
+
+// GetReferralDashboard gets the referred users, commissions and earnings aggregates for a referrer
+func (rs *ReferralService) GetReferralDashboard(ctx context.Context, userID primitive.ObjectID, page, limit int64, granularity string) (*models.ReferralDashboard, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	skip := (page - 1) * limit
+
+	user, err := rs.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	referralCount, err := rs.userRepo.CountByReferrer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	activeReferrals, err := rs.referralRepo.CountActiveReferrals(ctx, userID, time.Now().Add(-ActiveReferralWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	// Referred users, masked so referrers never see full personal details
+	referred, err := rs.userRepo.ListByReferrer(ctx, userID, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	referredIDs := make([]primitive.ObjectID, 0, len(referred))
+	for _, u := range referred {
+		referredIDs = append(referredIDs, u.ID)
+	}
+
+	totals, err := rs.referralRepo.SumByReferredUser(ctx, userID, referredIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	referredUsers := make([]models.ReferredUser, 0, len(referred))
+	for _, u := range referred {
+		referredUsers = append(referredUsers, models.ReferredUser{
+			ID:              u.ID,
+			Username:        utils.MaskString(u.Username),
+			Email:           utils.MaskEmail(u.Email),
+			IsActive:        u.IsActive,
+			IsEmailVerified: u.IsEmailVerified,
+			TotalCommission: totals[u.ID],
+			JoinedAt:        u.CreatedAt,
+		})
+	}
+
+	commissions, err := rs.referralRepo.ListByReferrer(ctx, userID, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	commissionCount, err := rs.referralRepo.CountByReferrer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Daily buckets cover the last 30 days, monthly buckets the last 12 months
+	since := time.Now().AddDate(0, 0, -30)
+	if granularity == "month" {
+		since = time.Now().AddDate(0, -12, 0)
+	} else {
+		granularity = "day"
+	}
+
+	earnings, err := rs.referralRepo.GetEarningsOverTime(ctx, userID, since, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReferralDashboard{
+		ReferralCode:     user.ReferralCode,
+		ReferralCount:    referralCount,
+		ActiveReferrals:  activeReferrals,
+		TotalEarnings:    user.ReferralEarnings,
+		ReferredUsers:    referredUsers,
+		Commissions:      commissions,
+		CommissionCount:  commissionCount,
+		EarningsOverTime: earnings,
+	}, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/config"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript keeps one sorted-set member per request scored by its time in milliseconds.
+// Expired requests are dropped, then the request is admitted if the window has room, all in one
+// atomic step. Returns {allowed, remaining, milliseconds until the oldest request leaves the window}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// RateLimitDecision represents the outcome of counting one request against a policy
+type RateLimitDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // until a slot frees up
+}
+
+// RequestLimiter enforces the configured rate limit policies with a Redis sliding window,
+// falling back to per-process fixed windows while Redis is unavailable
+type RequestLimiter struct {
+	redis    *redis.Client
+	policies map[string]config.RateLimitPolicy
+	fallback *fixedWindowCounter
+	degraded atomic.Bool
+}
+
+// NewRequestLimiter creates a new request limiter
+func NewRequestLimiter(authRepo *repositories.AuthRepository, rateLimitConfig *config.RateLimitConfig) *RequestLimiter {
+	return &RequestLimiter{
+		redis:    authRepo.GetRedis(),
+		policies: rateLimitConfig.Policies,
+		fallback: newFixedWindowCounter(),
+	}
+}
+
+// Policy gets a rate limit policy by name
+func (l *RequestLimiter) Policy(name string) (config.RateLimitPolicy, bool) {
+	policy, ok := l.policies[name]
+	return policy, ok
+}
+
+// Allow counts a request from a client (an IP or user ID) against a policy
+func (l *RequestLimiter) Allow(ctx context.Context, name string, policy config.RateLimitPolicy, client string) *RateLimitDecision {
+	key := fmt.Sprintf("rate_limit:%s:%s", name, client)
+	now := time.Now().UnixMilli()
+	window := policy.Window.Milliseconds()
+	member := fmt.Sprintf("%d-%d", now, rand.Int63())
+
+	result, err := slidingWindowScript.Run(ctx, l.redis, []string{key}, now, window, policy.Requests, member).Int64Slice()
+	if err != nil || len(result) != 3 {
+		// Keep limiting rather than failing open, with coarser per-process windows
+		if l.degraded.CompareAndSwap(false, true) {
+			fmt.Printf("Warning: rate limiter falling back to in-memory windows: %v\n", err)
+		}
+		return l.fallback.allow(key, policy)
+	}
+	if l.degraded.CompareAndSwap(true, false) {
+		fmt.Println("Rate limiter using Redis again")
+	}
+
+	return &RateLimitDecision{
+		Allowed:   result[0] == 1,
+		Limit:     policy.Requests,
+		Remaining: max(int(result[1]), 0), // negative if the policy was lowered mid-window
+		Reset:     time.Duration(result[2]) * time.Millisecond,
+	}
+}
+
+// fixedWindowCounter counts requests per key in fixed windows in memory
+type fixedWindowCounter struct {
+	mu        sync.Mutex
+	windows   map[string]*fixedWindow
+	lastSweep time.Time
+}
+
+type fixedWindow struct {
+	end   time.Time
+	count int
+}
+
+func newFixedWindowCounter() *fixedWindowCounter {
+	return &fixedWindowCounter{windows: make(map[string]*fixedWindow)}
+}
+
+// allow counts a request in the current window of a key
+func (f *fixedWindowCounter) allow(key string, policy config.RateLimitPolicy) *RateLimitDecision {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	f.sweep(now)
+
+	window, ok := f.windows[key]
+	if !ok || !now.Before(window.end) {
+		window = &fixedWindow{end: now.Truncate(policy.Window).Add(policy.Window)}
+		f.windows[key] = window
+	}
+
+	decision := &RateLimitDecision{Limit: policy.Requests, Reset: window.end.Sub(now)}
+	if window.count < policy.Requests {
+		window.count++
+		decision.Allowed = true
+	}
+	decision.Remaining = max(policy.Requests-window.count, 0)
+	return decision
+}
+
+// sweep drops finished windows, at most once a minute
+func (f *fixedWindowCounter) sweep(now time.Time) {
+	if now.Sub(f.lastSweep) < time.Minute {
+		return
+	}
+	f.lastSweep = now
+	for key, window := range f.windows {
+		if !now.Before(window.end) {
+			delete(f.windows, key)
+		}
+	}
+}
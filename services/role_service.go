@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// roleCacheTTL bounds how long other instances may act on stale role permissions
+const roleCacheTTL = 30 * time.Second
+
+type cachedRole struct {
+	role      *models.Role
+	expiresAt time.Time
+}
+
+type RoleService struct {
+	roleRepo       *repositories.RoleRepository
+	userRepo       *repositories.UserRepository
+	sessionService *SessionService
+	auditService   *AuditService
+
+	mu    sync.RWMutex
+	cache map[string]cachedRole
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(roleRepo *repositories.RoleRepository, userRepo *repositories.UserRepository, sessionService *SessionService, auditService *AuditService) *RoleService {
+	return &RoleService{
+		roleRepo:       roleRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		auditService:   auditService,
+		cache:          make(map[string]cachedRole),
+	}
+}
+
+// GetRole gets a role by name, served from a short-lived cache. Unknown roles return nil.
+func (s *RoleService) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	s.mu.RLock()
+	entry, ok := s.cache[name]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.role, nil
+	}
+
+	role, err := s.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[name] = cachedRole{role: role, expiresAt: time.Now().Add(roleCacheTTL)}
+	s.mu.Unlock()
+
+	return role, nil
+}
+
+// HasPermission checks if a role grants a permission
+func (s *RoleService) HasPermission(ctx context.Context, roleName, permission string) (bool, error) {
+	role, err := s.GetRole(ctx, roleName)
+	if err != nil {
+		return false, err
+	}
+	if role == nil {
+		return false, nil
+	}
+	return role.HasPermission(permission), nil
+}
+
+// IsStaffRole checks if a role gives access to the admin API
+func (s *RoleService) IsStaffRole(ctx context.Context, roleName string) (bool, error) {
+	role, err := s.GetRole(ctx, roleName)
+	if err != nil {
+		return false, err
+	}
+	if role == nil {
+		return false, nil
+	}
+	return role.IsStaff(), nil
+}
+
+// ListRoles gets all roles
+func (s *RoleService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+// CreateRole creates a new custom role
+func (s *RoleService) CreateRole(ctx context.Context, req *models.CreateRoleRequest) (*models.Role, error) {
+	if err := validatePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.roleRepo.GetByName(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("role already exists")
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(role.Name)
+	s.auditService.Record(ctx, models.AuditActionRoleCreate, models.AuditTargetRole, role.Name, nil, map[string]interface{}{
+		"description": role.Description,
+		"permissions": role.Permissions,
+	})
+	return role, nil
+}
+
+// UpdateRole updates a role's description and permissions
+func (s *RoleService) UpdateRole(ctx context.Context, name string, req *models.UpdateRoleRequest) (*models.Role, error) {
+	if name == models.RoleSuperAdmin && req.Permissions != nil {
+		return nil, errors.New("superadmin permissions cannot be changed")
+	}
+	if name == models.RoleUser && len(req.Permissions) > 0 {
+		return nil, errors.New("the user role cannot be granted permissions")
+	}
+
+	existing, err := s.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.New("role not found")
+	}
+
+	updateData := make(map[string]interface{})
+	if req.Description != nil {
+		updateData["description"] = *req.Description
+	}
+	if req.Permissions != nil {
+		if err := validatePermissions(req.Permissions); err != nil {
+			return nil, err
+		}
+		updateData["permissions"] = req.Permissions
+	}
+
+	if err := s.roleRepo.Update(ctx, name, updateData); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+
+	s.invalidate(name)
+	s.auditService.Record(ctx, models.AuditActionRoleUpdate, models.AuditTargetRole, name, map[string]interface{}{
+		"description": existing.Description,
+		"permissions": existing.Permissions,
+	}, updateData)
+	return s.roleRepo.GetByName(ctx, name)
+}
+
+// DeleteRole deletes a custom role that is no longer assigned to anyone
+func (s *RoleService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+	if role.IsSystem {
+		return errors.New("built-in roles cannot be deleted")
+	}
+
+	count, err := s.userRepo.CountByRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("role is still assigned to %d users", count)
+	}
+
+	if err := s.roleRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	s.invalidate(name)
+	s.auditService.Record(ctx, models.AuditActionRoleDelete, models.AuditTargetRole, name, map[string]interface{}{
+		"description": role.Description,
+		"permissions": role.Permissions,
+	}, nil)
+	return nil
+}
+
+// AssignRole changes a user's role. Only a superadmin may grant or revoke superadmin, and the
+// user's sessions are revoked so the new role is picked up on next login.
+func (s *RoleService) AssignRole(ctx context.Context, actorRole, userID, roleName string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+
+	if (roleName == models.RoleSuperAdmin || user.Role == models.RoleSuperAdmin) && actorRole != models.RoleSuperAdmin {
+		return errors.New("only a superadmin can change superadmin assignments")
+	}
+
+	if user.Role == roleName {
+		return nil
+	}
+
+	if err := s.userRepo.Update(ctx, objectID, map[string]interface{}{"role": roleName}); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionUserAssignRole, models.AuditTargetUser, userID,
+		map[string]interface{}{"role": user.Role}, map[string]interface{}{"role": roleName})
+
+	// Tokens carry the role, so force the user to sign in again
+	if err := s.sessionService.RevokeAllSessions(ctx, userID); err != nil {
+		fmt.Printf("Warning: Failed to revoke sessions after role change: %v\n", err)
+	}
+
+	return nil
+}
+
+// invalidate drops a role from the cache after it changes
+func (s *RoleService) invalidate(name string) {
+	s.mu.Lock()
+	delete(s.cache, name)
+	s.mu.Unlock()
+}
+
+// validatePermissions checks every permission in the list is known
+func validatePermissions(permissions []string) error {
+	for _, p := range permissions {
+		if !models.IsValidPermission(p) {
+			return fmt.Errorf("unknown permission: %s", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/HSouheil/bucketball_backend/security"
+	"github.com/google/uuid"
+)
+
+// SessionService handles signed-in device sessions
+type SessionService struct {
+	authRepo *repositories.AuthRepository
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(authRepo *repositories.AuthRepository) *SessionService {
+	return &SessionService{
+		authRepo: authRepo,
+	}
+}
+
+// CreateSession creates a new session for a user signing in from a client
+func (s *SessionService) CreateSession(ctx context.Context, userID string, client *models.ClientInfo) (*models.Session, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if client != nil {
+		session.IP = client.IP
+		session.UserAgent = client.UserAgent
+		session.Device = client.Device
+	}
+
+	if err := s.authRepo.SaveSession(ctx, session, security.RefreshTokenTTL()); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListSessions lists a user's active sessions, most recently used first
+func (s *SessionService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error) {
+	ids, err := s.authRepo.ListSessionIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []models.Session{}
+	for _, id := range ids {
+		session, err := s.authRepo.GetSession(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			// Session expired on its own, drop it from the index
+			s.authRepo.DeleteSession(ctx, userID, id)
+			continue
+		}
+
+		session.Current = session.ID == currentSessionID
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// RevokeSession revokes one of a user's sessions
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	session, err := s.authRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+
+	return s.authRepo.DeleteSession(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions revokes every session of a user except the one given
+func (s *SessionService) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) error {
+	ids, err := s.authRepo.ListSessionIDs(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	for _, id := range ids {
+		if id == keepSessionID {
+			continue
+		}
+		if err := s.authRepo.DeleteSession(ctx, userID, id); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RevokeAllSessions revokes every session and every token issued to a user so far
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID string) error {
+	// Keep the marker for as long as any previously issued refresh token could still be valid
+	if err := s.authRepo.SetSessionsRevokedAt(ctx, userID, time.Now(), security.RefreshTokenTTL()); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	ids, err := s.authRepo.ListSessionIDs(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := s.authRepo.DeleteSession(ctx, userID, id); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
+
+	return nil
+}
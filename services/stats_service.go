@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type StatsService struct {
+	statsRepo *repositories.StatsRepository
+	gameRepo  *repositories.GameRepository
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(statsRepo *repositories.StatsRepository, gameRepo *repositories.GameRepository) *StatsService {
+	return &StatsService{
+		statsRepo: statsRepo,
+		gameRepo:  gameRepo,
+	}
+}
+
+// RecordRound adds a settled round and its results to the daily rollups
+func (s *StatsService) RecordRound(ctx context.Context, completedAt time.Time, adminSkim float64, results []models.GameResult) error {
+	roundDay := statsDay(completedAt)
+	days := map[time.Time]*models.DailyStats{
+		roundDay: {Rounds: 1, AdminSkim: adminSkim},
+	}
+
+	type userDay struct {
+		userID primitive.ObjectID
+		day    time.Time
+	}
+	userDays := make(map[userDay]*models.DailyUserStats)
+	playedRound := make(map[primitive.ObjectID]bool)
+
+	// Streaks follow settlement order
+	ordered := append([]models.GameResult(nil), results...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].CreatedAt.Equal(ordered[j].CreatedAt) {
+			return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+		}
+		return ordered[i].ID.Hex() < ordered[j].ID.Hex()
+	})
+
+	for i := range ordered {
+		result := &ordered[i]
+		day := statsDay(result.CreatedAt)
+		if days[day] == nil {
+			days[day] = &models.DailyStats{}
+		}
+		key := userDay{userID: result.UserID, day: day}
+		if userDays[key] == nil {
+			userDays[key] = &models.DailyUserStats{Balls: make(map[string]models.DailyBallStats)}
+		}
+
+		global, user := days[day], userDays[key]
+		ballID := strconv.Itoa(result.BallID)
+		ball := user.Balls[ballID]
+
+		// The round counts once per player, on the day of their first result in it
+		if !playedRound[result.UserID] {
+			playedRound[result.UserID] = true
+			user.Rounds++
+		}
+		global.Bets++
+		user.Bets++
+		ball.Bets++
+		global.Wagered += result.BetAmount
+		user.Wagered += result.BetAmount
+		ball.Wagered += result.BetAmount
+		global.Payouts += result.WinAmount
+		user.Payouts += result.WinAmount
+		ball.Payouts += result.WinAmount
+		user.Profit += result.Profit
+		ball.Profit += result.Profit
+		if result.Won {
+			global.Wins++
+			user.Wins++
+			ball.Wins++
+			if user.BiggestWin == nil || result.Profit > user.BiggestWin.Profit {
+				user.BiggestWin = result
+			}
+		}
+		if result.Pushed {
+			global.Pushes++
+			user.Pushes++
+			ball.Pushes++
+		}
+		user.Balls[ballID] = ball
+		user.Streaks = user.Streaks.Then(models.ResultStreaks(result))
+	}
+
+	// Player rollups go first: creating one means a new distinct player for that day
+	for key, delta := range userDays {
+		created, err := s.statsRepo.AddUserDay(ctx, key.userID, key.day, delta)
+		if err != nil {
+			return err
+		}
+		if created {
+			days[key.day].Players++
+		}
+	}
+
+	for day, delta := range days {
+		if err := s.statsRepo.AddDay(ctx, day, delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backfill recomputes the rollups of every day from from through to (inclusive) from history
+func (s *StatsService) Backfill(ctx context.Context, from, to time.Time) error {
+	start := statsDay(from)
+	end := statsDay(to).AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return errors.New("invalid date range: from must not be after to")
+	}
+	// Rounds settling today are still added to its rollups, which a rebuild would race with
+	if end.After(statsDay(time.Now())) {
+		return errors.New("invalid date range: the current UTC day cannot be rebuilt, rounds are still settling")
+	}
+	return s.statsRepo.Rebuild(ctx, start, end)
+}
+
+// GetGameStats gets overall game statistics from the daily rollups
+func (s *StatsService) GetGameStats(ctx context.Context) (map[string]interface{}, error) {
+	totals, err := s.statsRepo.SumStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Open rounds are not in the rollups yet
+	activeGames, err := s.gameRepo.CountGames(ctx, "active")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"total_games":       totals.Rounds + activeGames,
+		"completed_games":   totals.Rounds,
+		"active_games":      activeGames,
+		"total_bets_amount": totals.Wagered,
+		"total_payouts":     totals.Payouts,
+		"admin_skim":        totals.AdminSkim,
+	}, nil
+}
+
+// statsDay gets the UTC day a time falls on
+func statsDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
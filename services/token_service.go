@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/security"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// startSession starts a new session (refresh token family) for a user and issues its first token pair
+func (s *AuthService) startSession(ctx context.Context, user *models.User, client *models.ClientInfo) (*models.TokenPair, error) {
+	session, err := s.sessionService.CreateSession(ctx, user.ID.Hex(), client)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user, session)
+}
+
+// issueTokens issues an access token and a rotating refresh token within a session
+func (s *AuthService) issueTokens(ctx context.Context, user *models.User, session *models.Session) (*models.TokenPair, error) {
+	userID := user.ID.Hex()
+
+	// Generate token
+	accessToken, err := security.GenerateToken(userID, user.Email, user.Username, user.Role, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store token in Redis
+	if err := s.authRepo.SetToken(ctx, accessToken, userID, security.AccessTokenTTL()); err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := security.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.RefreshTokenRecord{
+		UserID:   userID,
+		FamilyID: session.ID,
+	}
+	if err := s.authRepo.SetRefreshToken(ctx, refreshToken, record, security.RefreshTokenTTL()); err != nil {
+		return nil, err
+	}
+
+	// Each rotation keeps the session alive for another refresh token lifetime
+	if err := s.authRepo.SaveSession(ctx, session, security.RefreshTokenTTL()); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(security.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// RefreshTokens rotates a refresh token and issues a new token pair in the same session.
+// Presenting a refresh token that was already rotated revokes the whole session.
+func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string, client *models.ClientInfo) (*models.TokenPair, error) {
+	record, err := s.authRepo.ConsumeRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refresh token: %w", err)
+	}
+
+	if record == nil {
+		// Not a live token: if it was rotated before, someone is replaying it
+		familyID, err := s.authRepo.GetUsedRefreshTokenFamily(ctx, refreshToken)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read refresh token: %w", err)
+		}
+		if familyID != "" {
+			if session, _ := s.authRepo.GetSession(ctx, familyID); session != nil {
+				if err := s.authRepo.DeleteSession(ctx, session.UserID, session.ID); err != nil {
+					return nil, fmt.Errorf("failed to revoke session: %w", err)
+				}
+			}
+			return nil, errors.New("refresh token reuse detected, session revoked")
+		}
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// Remember the rotated token for as long as the session could still be used
+	if err := s.authRepo.MarkRefreshTokenUsed(ctx, refreshToken, record.FamilyID, security.RefreshTokenTTL()); err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	session, err := s.authRepo.GetSession(ctx, record.FamilyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if session == nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// Sessions started before a revoke-all (e.g. a password reset) are dead
+	revokedAt, err := s.authRepo.GetSessionsRevokedAt(ctx, record.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token status: %w", err)
+	}
+	if !revokedAt.IsZero() && !session.CreatedAt.After(revokedAt) {
+		s.authRepo.DeleteSession(ctx, session.UserID, session.ID)
+		return nil, errors.New("invalid refresh token")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(record.UserID)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if !user.IsActive {
+		s.authRepo.DeleteSession(ctx, session.UserID, session.ID)
+		return nil, errors.New("account is deactivated")
+	}
+
+	session.LastSeenAt = time.Now()
+	if client != nil {
+		session.IP = client.IP
+	}
+
+	return s.issueTokens(ctx, user, session)
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/HSouheil/bucketball_backend/security"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TwoFactorIssuer       = "BucketBall"
+	TwoFactorChallengeTTL = 5 * time.Minute
+	TwoFactorMaxAttempts  = 5
+	RecoveryCodeCount     = 10
+)
+
+// BeginTwoFactorSetup generates a new TOTP secret for a user, pending confirmation
+func (s *AuthService) BeginTwoFactorSetup(ctx context.Context, userID string) (*models.TwoFactorSetup, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	// The secret only becomes active once the user proves their app generates valid codes
+	updateData := map[string]interface{}{
+		"two_factor_pending_secret": secret,
+	}
+	if err := s.userRepo.Update(ctx, user.ID, updateData); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: security.TOTPURI(TwoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables 2FA once a code from the pending secret is verified and returns recovery codes
+func (s *AuthService) ConfirmTwoFactor(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return s.enableTwoFactor(ctx, user, code)
+}
+
+// enableTwoFactor verifies a code against the pending secret, activates it and issues recovery codes
+func (s *AuthService) enableTwoFactor(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TwoFactorPendingSecret == "" {
+		return nil, errors.New("no two-factor setup in progress")
+	}
+
+	valid, err := s.verifyTOTP(ctx, user.ID, user.TwoFactorPendingSecret, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	updateData := map[string]interface{}{
+		"two_factor_enabled":        true,
+		"two_factor_secret":         user.TwoFactorPendingSecret,
+		"two_factor_pending_secret": "",
+		"two_factor_recovery_codes": hashes,
+	}
+	if err := s.userRepo.Update(ctx, user.ID, updateData); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor turns off 2FA after re-checking the password and a current code
+func (s *AuthService) DisableTwoFactor(ctx context.Context, userID, password, code string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	isStaff, err := s.roleService.IsStaffRole(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if isStaff {
+		return errors.New("two-factor authentication is mandatory for admin accounts")
+	}
+	if !user.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if !security.CheckPasswordHash(password, user.Password) {
+		return errors.New("invalid password")
+	}
+
+	valid, err := s.verifyTwoFactorCode(ctx, user, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("invalid two-factor code")
+	}
+
+	updateData := map[string]interface{}{
+		"two_factor_enabled":        false,
+		"two_factor_secret":         "",
+		"two_factor_pending_secret": "",
+		"two_factor_recovery_codes": []string{},
+	}
+	return s.userRepo.Update(ctx, user.ID, updateData)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a current TOTP code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	valid, err := s.verifyTOTP(ctx, user.ID, user.TwoFactorSecret, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errors.New("invalid two-factor code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	updateData := map[string]interface{}{
+		"two_factor_recovery_codes": hashes,
+	}
+	if err := s.userRepo.Update(ctx, user.ID, updateData); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// requiresTwoFactor checks if the account must use two-factor authentication to sign in.
+// It is optional for players and mandatory for any role with admin access.
+func (s *AuthService) requiresTwoFactor(ctx context.Context, user *models.User) (bool, error) {
+	if user.TwoFactorEnabled {
+		return true, nil
+	}
+	return s.roleService.IsStaffRole(ctx, user.Role)
+}
+
+// createTwoFactorChallenge issues the short-lived token for the second login step
+func (s *AuthService) createTwoFactorChallenge(ctx context.Context, user *models.User) (*models.TwoFactorChallenge, error) {
+	challengeToken, err := security.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.TwoFactorChallengeRecord{UserID: user.ID.Hex()}
+	if err := s.authRepo.SetTwoFactorChallenge(ctx, challengeToken, record, TwoFactorChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		SetupRequired:     !user.TwoFactorEnabled,
+		ChallengeToken:    challengeToken,
+		ExpiresIn:         int64(TwoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// getChallengeUser resolves the user behind a pending login challenge
+func (s *AuthService) getChallengeUser(ctx context.Context, challengeToken string) (*models.TwoFactorChallengeRecord, *models.User, error) {
+	record, err := s.authRepo.GetTwoFactorChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if record == nil {
+		return nil, nil, errors.New("invalid or expired challenge")
+	}
+
+	user, err := s.GetUserByID(ctx, record.UserID)
+	if err != nil || !user.IsActive {
+		return nil, nil, errors.New("invalid or expired challenge")
+	}
+
+	return record, user, nil
+}
+
+// BeginChallengeSetup starts 2FA enrolment during login for accounts that must use it (admins)
+func (s *AuthService) BeginChallengeSetup(ctx context.Context, challengeToken string) (*models.TwoFactorSetup, error) {
+	_, user, err := s.getChallengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.BeginTwoFactorSetup(ctx, user.ID.Hex())
+}
+
+// VerifyTwoFactorLogin completes a login challenge with a TOTP or recovery code. When the
+// challenge was issued for mandatory enrolment it also enables 2FA and returns recovery codes.
+func (s *AuthService) VerifyTwoFactorLogin(ctx context.Context, challengeToken, code string, client *models.ClientInfo) (*models.User, *models.TokenPair, []string, error) {
+	record, user, err := s.getChallengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords
+	allowed, timeLeft, err := s.rateLimitSvc.CheckLoginRateLimit(ctx, user.Email, client.IP)
+	if err != nil {
+		return nil, nil, nil, errors.New("rate limit check failed")
+	}
+	if !allowed {
+		return nil, nil, nil, fmt.Errorf("too many login attempts. Please try again in %v", timeLeft.Round(time.Minute))
+	}
+
+	var recoveryCodes []string
+	if user.TwoFactorEnabled {
+		valid, verr := s.verifyTwoFactorCode(ctx, user, code)
+		if verr == nil && !valid {
+			verr = errors.New("invalid two-factor code")
+		}
+		err = verr
+	} else {
+		recoveryCodes, err = s.enableTwoFactor(ctx, user, code)
+	}
+
+	if err != nil {
+		s.rateLimitSvc.RecordLoginAttempt(ctx, user.Email, client.IP, false)
+
+		record.Attempts++
+		if record.Attempts >= TwoFactorMaxAttempts {
+			s.authRepo.DeleteTwoFactorChallenge(ctx, challengeToken)
+		} else {
+			s.authRepo.UpdateTwoFactorChallenge(ctx, challengeToken, record)
+		}
+		return nil, nil, nil, err
+	}
+
+	// Challenges are single use
+	if err := s.authRepo.DeleteTwoFactorChallenge(ctx, challengeToken); err != nil {
+		return nil, nil, nil, err
+	}
+	s.rateLimitSvc.RecordLoginAttempt(ctx, user.Email, client.IP, true)
+
+	if recoveryCodes != nil {
+		user.TwoFactorEnabled = true
+	}
+
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return user, tokens, recoveryCodes, nil
+}
+
+// verifyTwoFactorCode checks a TOTP code, or consumes a recovery code
+func (s *AuthService) verifyTwoFactorCode(ctx context.Context, user *models.User, code string) (bool, error) {
+	if len(code) == security.TOTPDigits {
+		return s.verifyTOTP(ctx, user.ID, user.TwoFactorSecret, code)
+	}
+
+	return s.userRepo.ConsumeRecoveryCode(ctx, user.ID, security.HashRecoveryCode(code))
+}
+
+// verifyTOTP checks a TOTP code and rejects codes that were already used
+func (s *AuthService) verifyTOTP(ctx context.Context, userID primitive.ObjectID, secret, code string) (bool, error) {
+	step, ok := security.ValidateTOTPCode(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// Remember the step for the whole window in which the same code would still validate
+	window := time.Duration(2*security.TOTPSkew+1) * security.TOTPPeriod * time.Second
+	fresh, err := s.authRepo.MarkTOTPStepUsed(ctx, userID.Hex(), step, window)
+	if err != nil {
+		return false, err
+	}
+
+	return fresh, nil
+}
+
+// generateRecoveryCodes generates recovery codes and their storage hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes, err := security.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, security.HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
@@ -3,40 +3,54 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/HSouheil/bucketball_backend/models"
 	"github.com/HSouheil/bucketball_backend/repositories"
+	"github.com/HSouheil/bucketball_backend/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type UserService struct {
-	userRepo *repositories.UserRepository
+	userRepo        *repositories.UserRepository
+	transactionRepo *repositories.TransactionRepository
+	sessionService  *SessionService
+	auditService    *AuditService
+	privacyService  *PrivacyService
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo *repositories.UserRepository) *UserService {
+func NewUserService(userRepo *repositories.UserRepository, transactionRepo *repositories.TransactionRepository, sessionService *SessionService, auditService *AuditService, privacyService *PrivacyService) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		sessionService:  sessionService,
+		auditService:    auditService,
+		privacyService:  privacyService,
 	}
 }
 
-// GetUsers gets a list of users with pagination
-func (s *UserService) GetUsers(ctx context.Context, page, limit int64) ([]*models.User, int64, error) {
-	if page < 1 {
-		page = 1
+// GetUsers searches users with filters, sorting and pagination
+func (s *UserService) GetUsers(ctx context.Context, filter *models.UserFilter, page, limit int64) ([]*models.User, int64, error) {
+	if filter.SortBy != "" && !isUserSortField(filter.SortBy) {
+		return nil, 0, fmt.Errorf("invalid sort field: %s", filter.SortBy)
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	if filter.MinBalance != nil && filter.MaxBalance != nil && *filter.MinBalance > *filter.MaxBalance {
+		return nil, 0, errors.New("invalid balance range: min_balance is greater than max_balance")
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return nil, 0, errors.New("invalid date range: created_from is after created_to")
 	}
 
-	skip := (page - 1) * limit
+	skip, limit := pageBounds(page, limit)
 
-	users, err := s.userRepo.List(ctx, skip, limit)
+	users, err := s.userRepo.Search(ctx, filter, skip, limit)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	total, err := s.userRepo.Count(ctx)
+	total, err := s.userRepo.CountMatching(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -44,6 +58,16 @@ func (s *UserService) GetUsers(ctx context.Context, page, limit int64) ([]*model
 	return users, total, nil
 }
 
+// isUserSortField checks if users can be sorted by a field
+func isUserSortField(field string) bool {
+	for _, f := range models.UserSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
 // GetUserByID gets a user by ID
 func (s *UserService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(userID)
@@ -55,14 +79,14 @@ func (s *UserService) GetUserByID(ctx context.Context, userID string) (*models.U
 }
 
 // UpdateUser updates a user (admin only)
-func (s *UserService) UpdateUser(ctx context.Context, userID string, req *models.UpdateUserRequest) error {
+func (s *UserService) UpdateUser(ctx context.Context, userID string, req *models.AdminUpdateUserRequest) error {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
 
 	// Check if user exists
-	_, err = s.userRepo.GetByID(ctx, objectID)
+	user, err := s.userRepo.GetByID(ctx, objectID)
 	if err != nil {
 		return errors.New("user not found")
 	}
@@ -86,15 +110,34 @@ func (s *UserService) UpdateUser(ctx context.Context, userID string, req *models
 	if req.LastName != nil {
 		updateData["last_name"] = *req.LastName
 	}
+	if req.PhoneNumber != nil {
+		updateData["phone_number"] = *req.PhoneNumber
+	}
+	if req.Location != nil {
+		updateData["location"] = *req.Location
+	}
 
 	if len(updateData) == 0 {
 		return errors.New("no fields to update")
 	}
 
-	return s.userRepo.Update(ctx, objectID, updateData)
+	before := map[string]interface{}{
+		"username":     user.Username,
+		"first_name":   user.FirstName,
+		"last_name":    user.LastName,
+		"phone_number": user.PhoneNumber,
+		"location":     user.Location,
+	}
+
+	if err := s.userRepo.Update(ctx, objectID, updateData); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, models.AuditActionUserUpdate, models.AuditTargetUser, userID, before, updateData)
+	return nil
 }
 
-// DeleteUser deletes a user (admin only)
+// DeleteUser erases a user's personal data, keeping their financial records (admin only)
 func (s *UserService) DeleteUser(ctx context.Context, userID string) error {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
@@ -102,12 +145,12 @@ func (s *UserService) DeleteUser(ctx context.Context, userID string) error {
 	}
 
 	// Check if user exists
-	_, err = s.userRepo.GetByID(ctx, objectID)
+	user, err := s.userRepo.GetByID(ctx, objectID)
 	if err != nil {
 		return errors.New("user not found")
 	}
 
-	return s.userRepo.Delete(ctx, objectID)
+	return s.privacyService.EraseUser(ctx, user)
 }
 
 // ToggleUserStatus toggles user active status (admin only)
@@ -133,6 +176,123 @@ func (s *UserService) ToggleUserStatus(ctx context.Context, userID string) (bool
 		return false, err
 	}
 
+	s.auditService.Record(ctx, models.AuditActionUserToggleStatus, models.AuditTargetUser, userID,
+		map[string]interface{}{"is_active": user.IsActive}, updateData)
+
+	// A deactivated user must be signed out of every device immediately
+	if !newStatus {
+		if err := s.sessionService.RevokeAllSessions(ctx, userID); err != nil {
+			return false, err
+		}
+	}
+
 	return newStatus, nil
 }
 
+// AdjustBalance credits or debits a user's balance on behalf of an admin and records the
+// adjustment as a transaction attributed to that admin
+func (s *UserService) AdjustBalance(ctx context.Context, adminID, userID string, req *models.AdminBalanceAdjustmentRequest) (*models.Transaction, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	adminObjectID, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return nil, errors.New("invalid admin ID")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	delta := req.Amount
+	if req.Type == "subtract" {
+		delta = -req.Amount
+	}
+
+	updated, err := s.userRepo.AdjustBalance(ctx, objectID, delta)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("insufficient balance")
+		}
+		return nil, err
+	}
+
+	reason := utils.SanitizeString(req.Reason)
+	transaction := &models.Transaction{
+		UserID:      objectID,
+		Type:        "adjustment",
+		Amount:      delta,
+		Balance:     updated.Balance,
+		Description: fmt.Sprintf("Admin balance adjustment (%s)", req.Type),
+		AdminID:     &adminObjectID,
+		Reason:      reason,
+		Status:      "completed",
+	}
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, fmt.Errorf("balance adjusted but failed to record transaction: %w", err)
+	}
+
+	s.auditService.Record(ctx, models.AuditActionUserAdjustBalance, models.AuditTargetUser, userID,
+		map[string]interface{}{"balance": user.Balance},
+		map[string]interface{}{"balance": updated.Balance, "reason": reason, "transaction_id": transaction.ID.Hex()})
+
+	return transaction, nil
+}
+
+// GetUserTransactions gets a user's transactions with pagination
+func (s *UserService) GetUserTransactions(ctx context.Context, userID string, page, limit int64) ([]models.Transaction, int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, 0, errors.New("invalid user ID")
+	}
+
+	skip, limit := pageBounds(page, limit)
+
+	transactions, err := s.transactionRepo.ListByUser(ctx, objectID, skip, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.transactionRepo.CountByUser(ctx, objectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+// GetAdminAdjustments gets the balance adjustments an admin has made with pagination
+func (s *UserService) GetAdminAdjustments(ctx context.Context, adminID string, page, limit int64) ([]models.Transaction, int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return nil, 0, errors.New("invalid admin ID")
+	}
+
+	skip, limit := pageBounds(page, limit)
+
+	transactions, err := s.transactionRepo.ListByAdmin(ctx, objectID, skip, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.transactionRepo.CountByAdmin(ctx, objectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+// pageBounds normalizes page/limit query values into a skip and limit
+func pageBounds(page, limit int64) (int64, int64) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return (page - 1) * limit, limit
+}
@@ -5,20 +5,20 @@ import (
 	"errors"
 	"time"
 
-	"github.com/HSouheil/bucketball_backend/security"
+	"github.com/HSouheil/bucketball_backend/models"
 )
 
-// VerifyEmailAndGenerateToken verifies the OTP and generates a token for the user
-func (s *AuthService) VerifyEmailAndGenerateToken(ctx context.Context, email, otp string) (string, error) {
+// VerifyEmailAndGenerateToken verifies the OTP and generates a token pair for the user
+func (s *AuthService) VerifyEmailAndGenerateToken(ctx context.Context, email, otp string, client *models.ClientInfo) (*models.User, *models.TokenPair, error) {
 	// Verify OTP
 	if err := s.otpService.VerifyOTP(ctx, email, otp, "registration"); err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", errors.New("user not found")
+		return nil, nil, errors.New("user not found")
 	}
 
 	// Update user's email verification status
@@ -28,21 +28,18 @@ func (s *AuthService) VerifyEmailAndGenerateToken(ctx context.Context, email, ot
 	}
 
 	if err := s.userRepo.Update(ctx, user.ID, updateData); err != nil {
-		return "", errors.New("failed to update user verification status")
+		return nil, nil, errors.New("failed to update user verification status")
 	}
 
-	// Generate token
-	token, err := security.GenerateToken(user.ID.Hex(), user.Email, user.Username, user.Role)
-	if err != nil {
-		return "", err
-	}
+	user.IsEmailVerified = true
 
-	// Store token in Redis
-	if err := s.authRepo.SetToken(ctx, token, user.ID.Hex(), 24*time.Hour); err != nil {
-		return "", err
+	// Start a new session with a fresh token pair
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return token, nil
+	return user, tokens, nil
 }
 
 // ResendOTP resends the OTP to the user's email
@@ -59,7 +56,7 @@ func (s *AuthService) ResendOTP(ctx context.Context, email string) error {
 	}
 
 	// Generate and send new OTP
-	if err := s.otpService.GenerateAndSendOTP(ctx, user.Email, user.Username, "registration"); err != nil {
+	if err := s.otpService.GenerateAndSendOTP(ctx, user.Email, user.Username, user.Language, OTPTypeRegistration); err != nil {
 		return err
 	}
 
@@ -0,0 +1,131 @@
+// Package templates renders the localized transactional emails embedded in the binary.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email template names
+const (
+	EmailOTP              = "otp"
+	EmailPasswordReset    = "password_reset"
+	EmailChange           = "email_change"
+	EmailChangeNotice     = "email_change_notice"
+	EmailWithdrawalStatus = "withdrawal_status"
+	EmailBigWin           = "big_win"
+	EmailNotification     = "notification"
+	DefaultLocale         = "en"
+)
+
+// SupportedLocales lists the locales that have a full set of email templates
+var SupportedLocales = []string{"en", "fr"}
+
+var emailNames = []string{
+	EmailOTP,
+	EmailPasswordReset,
+	EmailChange,
+	EmailChangeNotice,
+	EmailWithdrawalStatus,
+	EmailBigWin,
+	EmailNotification,
+}
+
+//go:embed email
+var emailFS embed.FS
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// emailTemplates is keyed by locale, then template name. Parsing happens at startup so a
+// broken template fails fast instead of on the first send.
+var emailTemplates = mustParseEmails()
+
+// Email represents a rendered email
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// NormalizeLocale maps a language preference (e.g. "fr-CA" or an Accept-Language value)
+// to a supported locale
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_,;"); i > 0 {
+		locale = locale[:i]
+	}
+	for _, supported := range SupportedLocales {
+		if locale == supported {
+			return supported
+		}
+	}
+	return DefaultLocale
+}
+
+// IsSupportedLocale checks if a locale has its own set of templates
+func IsSupportedLocale(locale string) bool {
+	_, ok := emailTemplates[locale]
+	return ok
+}
+
+// RenderEmail renders the subject, HTML and plain-text parts of an email in the given locale.
+// Year and Subject are added to data for the shared layout.
+func RenderEmail(name, locale string, data map[string]interface{}) (*Email, error) {
+	tmpl, ok := emailTemplates[NormalizeLocale(locale)][name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template: %s", name)
+	}
+
+	view := make(map[string]interface{}, len(data)+2)
+	for k, v := range data {
+		view[k] = v
+	}
+	view["Year"] = time.Now().Year()
+
+	var subject bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", view); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	view["Subject"] = strings.TrimSpace(subject.String())
+
+	var text bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&text, "layout", view); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+
+	var html bytes.Buffer
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", view); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return &Email{
+		Subject: view["Subject"].(string),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// mustParseEmails parses every email template for every supported locale
+func mustParseEmails() map[string]map[string]emailTemplate {
+	parsed := make(map[string]map[string]emailTemplate, len(SupportedLocales))
+	for _, locale := range SupportedLocales {
+		parsed[locale] = make(map[string]emailTemplate, len(emailNames))
+		for _, name := range emailNames {
+			dir := "email/" + locale + "/"
+			html := htmltemplate.Must(htmltemplate.New(name).Option("missingkey=error").
+				ParseFS(emailFS, dir+"layout.html", dir+name+".html"))
+			text := texttemplate.Must(texttemplate.New(name).Option("missingkey=error").
+				ParseFS(emailFS, dir+"layout.txt", dir+name+".txt"))
+			parsed[locale][name] = emailTemplate{html: html, text: text}
+		}
+	}
+	return parsed
+}
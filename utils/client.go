@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/HSouheil/bucketball_backend/models"
+	"github.com/labstack/echo/v4"
+)
+
+// GetClientInfo collects the IP, user agent and device name of the requesting client
+func GetClientInfo(c echo.Context) *models.ClientInfo {
+	userAgent := c.Request().UserAgent()
+
+	// Apps may name the device explicitly, otherwise derive it from the user agent
+	device := SanitizeString(c.Request().Header.Get("X-Device-Name"))
+	if device == "" {
+		device = DescribeDevice(userAgent)
+	}
+	if len(device) > 100 {
+		device = device[:100]
+	}
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	return &models.ClientInfo{
+		IP:        c.RealIP(),
+		UserAgent: userAgent,
+		Device:    device,
+	}
+}
+
+// NewIPExtractor resolves the client IP behind the given trusted proxies. Forwarding headers are
+// only read when the request comes from a trusted proxy, in this order: the nearest untrusted
+// address in X-Forwarded-For, then X-Real-IP, then the proxy's own address. Without trusted
+// proxies the headers are ignored and the connection's address is used.
+func NewIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	direct := echo.ExtractIPDirect()
+	if len(trustedProxies) == 0 {
+		return direct
+	}
+
+	// Only the configured ranges are trusted, not Echo's loopback/private defaults
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	fromForwardedFor := echo.ExtractIPFromXFFHeader(options...)
+
+	isTrusted := func(ip net.IP) bool {
+		for _, proxy := range trustedProxies {
+			if proxy.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(req *http.Request) string {
+		peer := direct(req)
+		if peerIP := net.ParseIP(peer); peerIP == nil || !isTrusted(peerIP) {
+			return peer
+		}
+
+		if len(req.Header.Values(echo.HeaderXForwardedFor)) > 0 {
+			return fromForwardedFor(req)
+		}
+
+		realIP := strings.Trim(strings.TrimSpace(req.Header.Get(echo.HeaderXRealIP)), "[]")
+		if ip := net.ParseIP(realIP); ip != nil {
+			return ip.String()
+		}
+		return peer
+	}
+}
+
+// DescribeDevice builds a short human readable device description from a user agent
+func DescribeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	platform := "Unknown device"
+	switch {
+	case strings.Contains(ua, "iphone"):
+		platform = "iPhone"
+	case strings.Contains(ua, "ipad"):
+		platform = "iPad"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"):
+		platform = "Mac"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	browser := ""
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "dart/") || strings.Contains(ua, "okhttp"):
+		browser = "App"
+	}
+
+	if browser == "" {
+		return platform
+	}
+	return browser + " on " + platform
+}
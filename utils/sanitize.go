@@ -66,3 +66,21 @@ func PreventSQLInjection(input string) string {
 
 	return result
 }
+
+// MaskEmail hides most of the local part of an email address (e.g. "jo***@example.com")
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return MaskString(email)
+	}
+	return MaskString(email[:at]) + email[at:]
+}
+
+// MaskString keeps the first two characters of a string and hides the rest
+func MaskString(input string) string {
+	runes := []rune(input)
+	if len(runes) <= 2 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:2]) + "***"
+}